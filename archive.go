@@ -0,0 +1,125 @@
+package youtube
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// ArchiveEntry records a finished download
+type ArchiveEntry struct {
+	VideoID string    `json:"id"`
+	ItagNo  int       `json:"itag"`
+	Path    string    `json:"path"`
+	SHA256  string    `json:"sha256,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// Archive remembers downloaded videos so that they can be skipped next time
+type Archive interface {
+	// Lookup returns the latest entry of the format of the video, ok is false if it was never downloaded
+	Lookup(videoID string, itagNo int) (entry ArchiveEntry, ok bool, err error)
+	// Add records a download
+	Add(entry ArchiveEntry) error
+}
+
+// FileArchive is an Archive in an append-only file with one JSON entry per line.
+// It's safe for concurrent use, but not by several processes at the same time.
+type FileArchive struct {
+	mu      sync.Mutex
+	file    *os.File
+	entries map[archiveKey]ArchiveEntry
+}
+
+// archiveKey tells the formats of a video apart, each one is a file of its own
+type archiveKey struct {
+	videoID string
+	itagNo  int
+}
+
+var _ Archive = (*FileArchive)(nil)
+
+// OpenFileArchive reads the archive at path, the file is created if it doesn't exist
+func OpenFileArchive(path string) (*FileArchive, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &FileArchive{
+		file:    file,
+		entries: make(map[archiveKey]ArchiveEntry),
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		// a line cut off by a crash is skipped, the download is simply done again
+		var entry ArchiveEntry
+		if json.Unmarshal(line, &entry) != nil || entry.VideoID == "" {
+			continue
+		}
+		a.entries[archiveKey{entry.VideoID, entry.ItagNo}] = entry
+	}
+	if err = scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	// start new entries on a fresh line after a cut off one
+	if info, err := file.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err = file.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			_, err = file.Write([]byte{'\n'})
+		}
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	return a, nil
+}
+
+// Lookup returns the latest entry of the format of the video
+func (a *FileArchive) Lookup(videoID string, itagNo int) (ArchiveEntry, bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entry, ok := a.entries[archiveKey{videoID, itagNo}]
+	return entry, ok, nil
+}
+
+// Add appends the entry to the file
+func (a *FileArchive) Add(entry ArchiveEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// a single write per entry, so an entry is never interleaved with another one
+	if _, err = a.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	a.entries[archiveKey{entry.VideoID, entry.ItagNo}] = entry
+	return nil
+}
+
+// Close closes the file
+func (a *FileArchive) Close() error {
+	return a.file.Close()
+}
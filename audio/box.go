@@ -0,0 +1,150 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// countingReader keeps track of the position in the input
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// readBoxHeader reads the header of an MP4 box, size is -1 if the box reaches to the end of the input
+func readBoxHeader(r io.Reader) (typ string, size int64, headerSize int, err error) {
+	var header [16]byte
+	if _, err = io.ReadFull(r, header[:8]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = fmt.Errorf("%w: truncated box header", ErrUnsupportedInput)
+		}
+		return "", 0, 0, err
+	}
+
+	typ = string(header[4:8])
+	size = int64(binary.BigEndian.Uint32(header[:4]))
+	headerSize = 8
+
+	switch size {
+	case 0:
+		size = -1
+	case 1:
+		if _, err = io.ReadFull(r, header[8:16]); err != nil {
+			return "", 0, 0, err
+		}
+		size = int64(binary.BigEndian.Uint64(header[8:16]))
+		headerSize = 16
+	}
+
+	if size >= 0 && size < int64(headerSize) {
+		return "", 0, 0, fmt.Errorf("%w: invalid size of box %q", ErrUnsupportedInput, typ)
+	}
+	return typ, size, headerSize, nil
+}
+
+// readPayload reads the rest of a box, only used for the small boxes
+func readPayload(r io.Reader, size int64, headerSize int) ([]byte, error) {
+	const maxPayload = 64 << 20
+	if size < 0 || size-int64(headerSize) > maxPayload {
+		return nil, fmt.Errorf("%w: box too big", ErrUnsupportedInput)
+	}
+
+	payload := make([]byte, size-int64(headerSize))
+	_, err := io.ReadFull(r, payload)
+	return payload, err
+}
+
+// skip discards n bytes of the input
+func skip(r io.Reader, n int64) error {
+	_, err := io.CopyN(io.Discard, r, n)
+	return err
+}
+
+// forEachBox calls fn with the payload of every box in data
+func forEachBox(data []byte, fn func(typ string, payload []byte) error) error {
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data))
+		headerSize := uint64(8)
+		if size == 1 && len(data) >= 16 {
+			size = binary.BigEndian.Uint64(data[8:])
+			headerSize = 16
+		} else if size == 0 {
+			size = uint64(len(data))
+		}
+		if size < headerSize || size > uint64(len(data)) {
+			return fmt.Errorf("%w: invalid box size", ErrUnsupportedInput)
+		}
+
+		if err := fn(string(data[4:8]), data[headerSize:size]); err != nil {
+			return err
+		}
+		data = data[size:]
+	}
+	return nil
+}
+
+// findBox returns the payload of the first box found by the path, nil if there is none
+func findBox(data []byte, path ...string) []byte {
+	var found []byte
+	forEachBox(data, func(typ string, payload []byte) error {
+		if found != nil || typ != path[0] {
+			return nil
+		}
+		if len(path) == 1 {
+			found = payload
+		} else {
+			found = findBox(payload, path[1:]...)
+		}
+		return nil
+	})
+	return found
+}
+
+// makeBox builds a box of the given type around the parts
+func makeBox(typ string, parts ...[]byte) []byte {
+	payload := concat(parts...)
+	return concat(u32(uint32(len(payload)+8)), []byte(typ), payload)
+}
+
+// fullBox is the version and flags header of a full box
+func fullBox(version byte, flags uint32) []byte {
+	return u32(uint32(version)<<24 | flags&0xffffff)
+}
+
+func concat(parts ...[]byte) []byte {
+	var n int
+	for _, p := range parts {
+		n += len(p)
+	}
+
+	b := make([]byte, 0, n)
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+func u16(v uint16) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, v)
+	return b
+}
+
+func u32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+func u64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
@@ -0,0 +1,384 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// sample is a single audio frame found in a fragment
+type sample struct {
+	offset   int64
+	size     uint32
+	duration uint32
+}
+
+// fragmentedTrack is what RemuxM4A needs to know about the input
+type fragmentedTrack struct {
+	timescale uint32
+	language  uint16
+	stsd      []byte
+
+	defaultDuration uint32
+	defaultSize     uint32
+
+	durations []uint32
+	sizes     []uint32
+}
+
+// RemuxM4A converts a fragmented MP4 audio stream (as served by DASH) into a regular MP4 audio file.
+// The samples are written into a single mdat followed by the moov box with the sample tables and the tags.
+func RemuxM4A(dst io.WriteSeeker, src io.Reader, tags Tags) error {
+	in := &countingReader{r: bufio.NewReader(src)}
+	track := &fragmentedTrack{}
+
+	// ftyp and the mdat header, its size is patched at the end
+	mdatStart, err := dst.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	ftyp := makeBox("ftyp", []byte("M4A "), u32(0x200), []byte("M4A mp42isom"))
+	if _, err = dst.Write(ftyp); err != nil {
+		return err
+	}
+	mdatStart += int64(len(ftyp))
+	if _, err = dst.Write(append(u32(1), append([]byte("mdat"), u64(0)...)...)); err != nil {
+		return err
+	}
+
+	var pending []sample
+	var mdatSize int64
+	for {
+		boxStart := in.n
+		typ, size, headerSize, err := readBoxHeader(in)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch typ {
+		case "moov":
+			moov, err := readPayload(in, size, headerSize)
+			if err != nil {
+				return err
+			}
+			if err = track.parseMoov(moov); err != nil {
+				return err
+			}
+
+		case "moof":
+			if track.stsd == nil {
+				return fmt.Errorf("%w: moof before moov", ErrUnsupportedInput)
+			}
+			moof, err := readPayload(in, size, headerSize)
+			if err != nil {
+				return err
+			}
+			samples, err := track.parseMoof(moof, boxStart)
+			if err != nil {
+				return err
+			}
+			pending = append(pending, samples...)
+
+		case "mdat":
+			// copy the samples of the previous moof in their order
+			end := in.n + size - int64(headerSize)
+			if size < 0 {
+				end = math.MaxInt64
+			}
+			for _, s := range pending {
+				if s.offset < in.n || s.offset+int64(s.size) > end {
+					return fmt.Errorf("%w: sample outside of mdat", ErrUnsupportedInput)
+				}
+				if err = skip(in, s.offset-in.n); err != nil {
+					return err
+				}
+				if _, err = io.CopyN(dst, in, int64(s.size)); err != nil {
+					return err
+				}
+				track.durations = append(track.durations, s.duration)
+				track.sizes = append(track.sizes, s.size)
+				mdatSize += int64(s.size)
+			}
+			pending = nil
+
+			if size < 0 {
+				if _, err = io.Copy(io.Discard, in); err != nil {
+					return err
+				}
+			} else if err = skip(in, end-in.n); err != nil {
+				return err
+			}
+
+		default:
+			// ftyp, styp, sidx, free, ...
+			if size < 0 {
+				return fmt.Errorf("%w: box %q without size", ErrUnsupportedInput, typ)
+			}
+			if err = skip(in, size-int64(headerSize)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(track.sizes) == 0 {
+		return ErrNoAudio
+	}
+
+	// patch the size of mdat, then add moov behind it
+	if _, err = dst.Seek(mdatStart+8, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err = dst.Write(u64(uint64(mdatSize + 16))); err != nil {
+		return err
+	}
+	if _, err = dst.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+
+	_, err = dst.Write(track.moov(mdatStart+16, tags))
+	return err
+}
+
+// parseMoov reads the codec configuration and the fragment defaults
+func (t *fragmentedTrack) parseMoov(moov []byte) error {
+	stsd := findBox(moov, "trak", "mdia", "minf", "stbl", "stsd")
+	mdhd := findBox(moov, "trak", "mdia", "mdhd")
+	if stsd == nil || len(mdhd) < 24 {
+		return fmt.Errorf("%w: missing stsd or mdhd", ErrUnsupportedInput)
+	}
+
+	t.stsd = makeBox("stsd", stsd)
+	if mdhd[0] == 1 {
+		if len(mdhd) < 36 {
+			return fmt.Errorf("%w: short mdhd", ErrUnsupportedInput)
+		}
+		t.timescale = binary.BigEndian.Uint32(mdhd[20:])
+		t.language = binary.BigEndian.Uint16(mdhd[32:])
+	} else {
+		t.timescale = binary.BigEndian.Uint32(mdhd[12:])
+		t.language = binary.BigEndian.Uint16(mdhd[20:])
+	}
+
+	if trex := findBox(moov, "mvex", "trex"); len(trex) >= 24 {
+		t.defaultDuration = binary.BigEndian.Uint32(trex[12:])
+		t.defaultSize = binary.BigEndian.Uint32(trex[16:])
+	}
+	return nil
+}
+
+// parseMoof returns the samples of a fragment with their absolute offsets in the input
+func (t *fragmentedTrack) parseMoof(moof []byte, moofStart int64) ([]sample, error) {
+	var samples []sample
+
+	err := forEachBox(moof, func(typ string, traf []byte) error {
+		if typ != "traf" {
+			return nil
+		}
+
+		tfhd := findBox(traf, "tfhd")
+		if len(tfhd) < 8 {
+			return fmt.Errorf("%w: missing tfhd", ErrUnsupportedInput)
+		}
+
+		flags := binary.BigEndian.Uint32(tfhd) & 0xffffff
+		fields := tfhd[8:]
+		base := moofStart
+		duration, size := t.defaultDuration, t.defaultSize
+
+		if len(fields) < optionalFieldsSize(flags, map[uint32]int{0x01: 8, 0x02: 4, 0x08: 4, 0x10: 4}) {
+			return fmt.Errorf("%w: short tfhd", ErrUnsupportedInput)
+		}
+
+		if flags&0x01 != 0 {
+			base = int64(binary.BigEndian.Uint64(fields))
+			fields = fields[8:]
+		}
+		if flags&0x02 != 0 {
+			fields = fields[4:]
+		}
+		if flags&0x08 != 0 {
+			duration = binary.BigEndian.Uint32(fields)
+			fields = fields[4:]
+		}
+		if flags&0x10 != 0 {
+			size = binary.BigEndian.Uint32(fields)
+		}
+
+		offset := base
+		return forEachBox(traf, func(typ string, trun []byte) error {
+			if typ != "trun" {
+				return nil
+			}
+			if len(trun) < 8 {
+				return fmt.Errorf("%w: short trun", ErrUnsupportedInput)
+			}
+
+			flags := binary.BigEndian.Uint32(trun) & 0xffffff
+			count := binary.BigEndian.Uint32(trun[4:])
+			fields := trun[8:]
+
+			if len(fields) < optionalFieldsSize(flags, map[uint32]int{0x01: 4, 0x04: 4}) {
+				return fmt.Errorf("%w: short trun", ErrUnsupportedInput)
+			}
+			if flags&0x01 != 0 {
+				offset = base + int64(int32(binary.BigEndian.Uint32(fields)))
+				fields = fields[4:]
+			}
+			if flags&0x04 != 0 {
+				fields = fields[4:]
+			}
+
+			// every sample has the fields selected by the flags
+			perSample := optionalFieldsSize(flags, map[uint32]int{0x100: 4, 0x200: 4, 0x400: 4, 0x800: 4})
+			if uint64(len(fields)) < uint64(count)*uint64(perSample) {
+				return fmt.Errorf("%w: short trun", ErrUnsupportedInput)
+			}
+
+			for i := uint32(0); i < count; i++ {
+				s := sample{offset: offset, duration: duration, size: size}
+				if flags&0x100 != 0 {
+					s.duration = binary.BigEndian.Uint32(fields)
+					fields = fields[4:]
+				}
+				if flags&0x200 != 0 {
+					s.size = binary.BigEndian.Uint32(fields)
+					fields = fields[4:]
+				}
+				if flags&0x400 != 0 {
+					fields = fields[4:]
+				}
+				if flags&0x800 != 0 {
+					fields = fields[4:]
+				}
+
+				samples = append(samples, s)
+				offset += int64(s.size)
+			}
+			return nil
+		})
+	})
+
+	return samples, err
+}
+
+// optionalFieldsSize sums the sizes of the fields present according to flags
+func optionalFieldsSize(flags uint32, sizes map[uint32]int) int {
+	var total int
+	for bit, size := range sizes {
+		if flags&bit != 0 {
+			total += size
+		}
+	}
+	return total
+}
+
+// moov builds the movie box for all samples stored in one chunk at dataOffset
+func (t *fragmentedTrack) moov(dataOffset int64, tags Tags) []byte {
+	var duration uint64
+	for _, d := range t.durations {
+		duration += uint64(d)
+	}
+
+	// time to sample, run length encoded
+	var stts [][]byte
+	for i := 0; i < len(t.durations); {
+		j := i
+		for j < len(t.durations) && t.durations[j] == t.durations[i] {
+			j++
+		}
+		stts = append(stts, u32(uint32(j-i)), u32(t.durations[i]))
+		i = j
+	}
+
+	stsz := [][]byte{fullBox(0, 0), u32(0), u32(uint32(len(t.sizes)))}
+	for _, size := range t.sizes {
+		stsz = append(stsz, u32(size))
+	}
+
+	chunkOffset := makeBox("stco", fullBox(0, 0), u32(1), u32(uint32(dataOffset)))
+	if dataOffset > math.MaxUint32 {
+		chunkOffset = makeBox("co64", fullBox(0, 0), u32(1), u64(uint64(dataOffset)))
+	}
+
+	stbl := makeBox("stbl",
+		t.stsd,
+		makeBox("stts", append([][]byte{fullBox(0, 0), u32(uint32(len(stts) / 2))}, stts...)...),
+		makeBox("stsc", fullBox(0, 0), u32(1), u32(1), u32(uint32(len(t.sizes))), u32(1)),
+		makeBox("stsz", stsz...),
+		chunkOffset,
+	)
+
+	minf := makeBox("minf",
+		makeBox("smhd", fullBox(0, 0), u16(0), u16(0)),
+		makeBox("dinf", makeBox("dref", fullBox(0, 0), u32(1), makeBox("url ", fullBox(0, 1)))),
+		stbl,
+	)
+
+	mdia := makeBox("mdia",
+		makeBox("mdhd", timeFields(t.timescale, duration), u16(t.language), u16(0)),
+		makeBox("hdlr", fullBox(0, 0), u32(0), []byte("soun"), make([]byte, 12), []byte("SoundHandler\x00")),
+		minf,
+	)
+
+	tkhd := makeBox("tkhd", tkhdFields(duration),
+		make([]byte, 8), u16(0), u16(0), u16(0x0100), u16(0), identityMatrix(), u32(0), u32(0))
+
+	mvhd := makeBox("mvhd", timeFields(t.timescale, duration),
+		u32(0x00010000), u16(0x0100), make([]byte, 10), identityMatrix(), make([]byte, 24), u32(2))
+
+	return makeBox("moov", mvhd, makeBox("trak", tkhd, mdia), tags.udta())
+}
+
+// udta builds the iTunes style metadata box
+func (t Tags) udta() []byte {
+	text := func(typ, value string) []byte {
+		if value == "" {
+			return nil
+		}
+		return makeBox(typ, makeBox("data", u32(1), u32(0), []byte(value)))
+	}
+
+	items := [][]byte{
+		text("\xa9nam", t.Title),
+		text("\xa9ART", t.Artist),
+		text("\xa9alb", t.Album),
+		text("\xa9too", "youtube"),
+	}
+
+	if len(t.Cover) > 0 {
+		dataType := uint32(13) // JPEG
+		if t.coverMimeType() == "image/png" {
+			dataType = 14
+		}
+		items = append(items, makeBox("covr", makeBox("data", u32(dataType), u32(0), t.Cover)))
+	}
+
+	hdlr := makeBox("hdlr", fullBox(0, 0), u32(0), []byte("mdirappl"), make([]byte, 8), []byte{0})
+	return makeBox("udta", makeBox("meta", fullBox(0, 0), hdlr, makeBox("ilst", items...)))
+}
+
+// timeFields are the shared fields of mvhd and mdhd, version 1 is only used for very long durations
+func timeFields(timescale uint32, duration uint64) []byte {
+	if duration > math.MaxUint32 {
+		return concat(fullBox(1, 0), u64(0), u64(0), u32(timescale), u64(duration))
+	}
+	return concat(fullBox(0, 0), u32(0), u32(0), u32(timescale), u32(uint32(duration)))
+}
+
+// tkhdFields are the leading fields of tkhd for the only, enabled track
+func tkhdFields(duration uint64) []byte {
+	const enabledInMovie = 0x000003
+	if duration > math.MaxUint32 {
+		return concat(fullBox(1, enabledInMovie), u64(0), u64(0), u32(1), u32(0), u64(duration))
+	}
+	return concat(fullBox(0, enabledInMovie), u32(0), u32(0), u32(1), u32(0), u32(uint32(duration)))
+}
+
+func identityMatrix() []byte {
+	return concat(u32(0x00010000), u32(0), u32(0), u32(0), u32(0x00010000), u32(0), u32(0), u32(0), u32(0x40000000))
+}
@@ -0,0 +1,147 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// topBox is a box at the top level of an MP4 file
+type topBox struct {
+	typ    string
+	offset int64
+	size   int64
+}
+
+// EmbedTags copies an MP4 or M4A file from src to dst with the tags in its moov box, e.g. to add a cover.
+// Existing metadata is replaced. If the moov box is in front of the media data, the chunk offsets are moved.
+func EmbedTags(dst io.Writer, src io.ReadSeeker, tags Tags) error {
+	boxes, err := scanBoxes(src)
+	if err != nil {
+		return err
+	}
+
+	moovIndex, mdatOffset := -1, int64(-1)
+	for i, box := range boxes {
+		switch box.typ {
+		case "moov":
+			moovIndex = i
+		case "mdat", "moof":
+			if mdatOffset < 0 {
+				mdatOffset = box.offset
+			}
+		}
+	}
+	if moovIndex < 0 {
+		return fmt.Errorf("%w: no moov box", ErrUnsupportedInput)
+	}
+
+	moovBox := boxes[moovIndex]
+	if _, err = src.Seek(moovBox.offset, io.SeekStart); err != nil {
+		return err
+	}
+	_, _, headerSize, err := readBoxHeader(src)
+	if err != nil {
+		return err
+	}
+	payload, err := readPayload(src, moovBox.size, headerSize)
+	if err != nil {
+		return err
+	}
+
+	// everything but the old metadata, which is replaced
+	var children [][]byte
+	err = forEachBox(payload, func(typ string, child []byte) error {
+		if typ != "udta" {
+			children = append(children, makeBox(typ, child))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	children = append(children, tags.udta())
+	moov := makeBox("moov", children...)
+
+	// the media data behind moov moves by the change of its size
+	if delta := int64(len(moov)) - moovBox.size; delta != 0 && mdatOffset > moovBox.offset {
+		if err = shiftChunkOffsets(moov[8:], delta); err != nil {
+			return err
+		}
+	}
+
+	for _, box := range boxes {
+		if box.typ == "moov" {
+			if _, err = dst.Write(moov); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err = src.Seek(box.offset, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err = io.CopyN(dst, src, box.size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanBoxes lists the boxes at the top level of the file
+func scanBoxes(src io.ReadSeeker) ([]topBox, error) {
+	end, err := src.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	var boxes []topBox
+	for offset := int64(0); offset < end; {
+		if _, err = src.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		typ, size, _, err := readBoxHeader(src)
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			size = end - offset
+		}
+		if offset+size > end {
+			return nil, fmt.Errorf("%w: box %q is truncated", ErrUnsupportedInput, typ)
+		}
+
+		boxes = append(boxes, topBox{typ: typ, offset: offset, size: size})
+		offset += size
+	}
+	return boxes, nil
+}
+
+// shiftChunkOffsets adds delta to the chunk offsets of all tracks in the moov payload, in place
+func shiftChunkOffsets(moov []byte, delta int64) error {
+	return forEachBox(moov, func(typ string, trak []byte) error {
+		if typ != "trak" {
+			return nil
+		}
+
+		stbl := findBox(trak, "mdia", "minf", "stbl")
+		if stco := findBox(stbl, "stco"); len(stco) >= 8 {
+			entries := stco[8:]
+			for i := 0; i+4 <= len(entries) && i/4 < int(binary.BigEndian.Uint32(stco[4:])); i += 4 {
+				offset := int64(binary.BigEndian.Uint32(entries[i:])) + delta
+				if offset < 0 || offset > math.MaxUint32 {
+					return fmt.Errorf("%w: chunk offset out of range", ErrUnsupportedInput)
+				}
+				binary.BigEndian.PutUint32(entries[i:], uint32(offset))
+			}
+		}
+		if co64 := findBox(stbl, "co64"); len(co64) >= 8 {
+			entries := co64[8:]
+			for i := 0; i+8 <= len(entries) && i/8 < int(binary.BigEndian.Uint32(co64[4:])); i += 8 {
+				binary.BigEndian.PutUint64(entries[i:], uint64(int64(binary.BigEndian.Uint64(entries[i:]))+delta))
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,118 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// oggCRCTable is the CRC-32 of Ogg: polynomial 0x04c11db7, not reflected
+var oggCRCTable = func() (table [256]uint32) {
+	for i := range table {
+		crc := uint32(i) << 24
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04c11db7
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+// oggWriter packs packets into the pages of a single logical Ogg stream
+type oggWriter struct {
+	w      io.Writer
+	serial uint32
+	seq    uint32
+
+	// the page being filled
+	segments  []byte
+	data      []byte
+	granule   int64
+	ended     bool // a packet ends on this page
+	continued bool // the page starts with the rest of a packet
+	eos       bool
+}
+
+// writePacket adds a packet, granule is the position after the packet
+func (o *oggWriter) writePacket(packet []byte, granule int64) error {
+	if len(o.data) >= maxPageSize {
+		if err := o.flush(); err != nil {
+			return err
+		}
+	}
+
+	for {
+		// a packet is split into segments of 255 bytes and a shorter one ending it
+		for len(packet) >= 255 && len(o.segments) < 255 {
+			o.segments = append(o.segments, 255)
+			o.data = append(o.data, packet[:255]...)
+			packet = packet[255:]
+		}
+
+		if len(o.segments) < 255 {
+			o.segments = append(o.segments, byte(len(packet)))
+			o.data = append(o.data, packet...)
+			o.granule = granule
+			o.ended = true
+			return nil
+		}
+
+		// the page is full, continue the packet on the next one
+		if err := o.flush(); err != nil {
+			return err
+		}
+		o.continued = true
+	}
+}
+
+// flush writes the current page
+func (o *oggWriter) flush() error {
+	if len(o.segments) == 0 && !o.eos {
+		return nil
+	}
+
+	var headerType byte
+	if o.continued {
+		headerType |= 0x01
+	}
+	if o.seq == 0 {
+		headerType |= 0x02
+	}
+	if o.eos {
+		headerType |= 0x04
+	}
+
+	// no packet ends on this page
+	granule := o.granule
+	if !o.ended {
+		granule = -1
+	}
+
+	page := make([]byte, 27, 27+len(o.segments)+len(o.data))
+	copy(page, "OggS")
+	page[5] = headerType
+	binary.LittleEndian.PutUint64(page[6:], uint64(granule))
+	binary.LittleEndian.PutUint32(page[14:], o.serial)
+	binary.LittleEndian.PutUint32(page[18:], o.seq)
+	page[26] = byte(len(o.segments))
+	page = append(page, o.segments...)
+	page = append(page, o.data...)
+
+	var crc uint32
+	for _, b := range page {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	binary.LittleEndian.PutUint32(page[22:], crc)
+
+	o.seq++
+	o.segments = o.segments[:0]
+	o.data = o.data[:0]
+	o.ended = false
+	o.continued = false
+
+	_, err := o.w.Write(page)
+	return err
+}
@@ -0,0 +1,355 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Matroska element IDs used by RemuxOpus
+const (
+	idSegment      = 0x18538067
+	idCluster      = 0x1F43B675
+	idTracks       = 0x1654AE6B
+	idTrackEntry   = 0xAE
+	idTrackNumber  = 0xD7
+	idCodecID      = 0x86
+	idCodecPrivate = 0x63A2
+	idBlockGroup   = 0xA0
+	idBlock        = 0xA1
+	idSimpleBlock  = 0xA3
+)
+
+// maxPageSize is the size at which a new Ogg page is started
+const maxPageSize = 4096
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// RemuxOpus converts a WebM stream with an Opus track into an Ogg Opus file
+func RemuxOpus(dst io.Writer, src io.Reader, tags Tags) error {
+	in := bufio.NewReader(src)
+	out := &oggWriter{w: dst, serial: 0x79746f70}
+
+	var opusTrack uint64
+	var samples int64
+	for {
+		id, err := readElementID(in)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		size, err := readElementSize(in)
+		if err != nil {
+			return err
+		}
+
+		switch id {
+		case idSegment, idCluster, idBlockGroup:
+			// descend into the children
+
+		case idTracks:
+			payload, err := readElement(in, size)
+			if err != nil {
+				return err
+			}
+			track, head, err := findOpusTrack(payload)
+			if err != nil {
+				return err
+			}
+			opusTrack = track
+
+			// the headers get a page each, the audio starts on a new page
+			if err = out.writePacket(head, 0); err != nil {
+				return err
+			}
+			if err = out.flush(); err != nil {
+				return err
+			}
+			if err = out.writePacket(tags.opusTags(), 0); err != nil {
+				return err
+			}
+			if err = out.flush(); err != nil {
+				return err
+			}
+
+		case idSimpleBlock, idBlock:
+			block, err := readElement(in, size)
+			if err != nil {
+				return err
+			}
+			if opusTrack == 0 {
+				return fmt.Errorf("%w: block before tracks", ErrUnsupportedInput)
+			}
+
+			packet, track, err := parseBlock(block)
+			if err != nil {
+				return err
+			}
+			if track != opusTrack {
+				continue
+			}
+
+			samples += opusPacketSamples(packet)
+			if err = out.writePacket(packet, samples); err != nil {
+				return err
+			}
+
+		default:
+			if size < 0 {
+				return fmt.Errorf("%w: element %x without size", ErrUnsupportedInput, id)
+			}
+			if err = skip(in, size); err != nil {
+				return err
+			}
+		}
+	}
+
+	if samples == 0 {
+		return ErrNoAudio
+	}
+
+	out.eos = true
+	return out.flush()
+}
+
+// findOpusTrack returns the number and the OpusHead of the Opus track
+func findOpusTrack(tracks []byte) (uint64, []byte, error) {
+	r := bytes.NewReader(tracks)
+	for r.Len() > 0 {
+		id, size, err := readElementHeader(r)
+		if err != nil {
+			return 0, nil, err
+		}
+		entry, err := readElement(r, size)
+		if err != nil {
+			return 0, nil, err
+		}
+		if id != idTrackEntry {
+			continue
+		}
+
+		var number uint64
+		var codec string
+		var private []byte
+
+		er := bytes.NewReader(entry)
+		for er.Len() > 0 {
+			id, size, err := readElementHeader(er)
+			if err != nil {
+				return 0, nil, err
+			}
+			value, err := readElement(er, size)
+			if err != nil {
+				return 0, nil, err
+			}
+
+			switch id {
+			case idTrackNumber:
+				for _, b := range value {
+					number = number<<8 | uint64(b)
+				}
+			case idCodecID:
+				codec = string(value)
+			case idCodecPrivate:
+				private = value
+			}
+		}
+
+		if codec == "A_OPUS" {
+			if !bytes.HasPrefix(private, []byte("OpusHead")) {
+				return 0, nil, fmt.Errorf("%w: Opus track without OpusHead", ErrUnsupportedInput)
+			}
+			return number, private, nil
+		}
+	}
+
+	return 0, nil, fmt.Errorf("%w: no Opus track", ErrUnsupportedInput)
+}
+
+// parseBlock returns the frame of a SimpleBlock or Block and its track number
+func parseBlock(block []byte) ([]byte, uint64, error) {
+	r := bytes.NewReader(block)
+	track, err := readElementSize(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// relative timecode (2 bytes) and flags
+	header := make([]byte, 3)
+	if _, err = io.ReadFull(r, header); err != nil {
+		return nil, 0, fmt.Errorf("%w: short block", ErrUnsupportedInput)
+	}
+	if header[2]&0x06 != 0 {
+		return nil, 0, fmt.Errorf("%w: laced blocks", ErrUnsupportedInput)
+	}
+
+	return block[len(block)-r.Len():], uint64(track), nil
+}
+
+// readElementHeader reads the ID and the size of an EBML element
+func readElementHeader(r byteReader) (uint32, int64, error) {
+	id, err := readElementID(r)
+	if err != nil {
+		return 0, 0, err
+	}
+	size, err := readElementSize(r)
+	return id, size, err
+}
+
+// readElementID reads an EBML ID, the length marker stays part of it
+func readElementID(r byteReader) (uint32, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	length := vintLength(first)
+	if length > 4 {
+		return 0, fmt.Errorf("%w: invalid element ID", ErrUnsupportedInput)
+	}
+
+	id := uint32(first)
+	for i := 1; i < length; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		id = id<<8 | uint32(b)
+	}
+	return id, nil
+}
+
+// readElementSize reads an EBML variable size integer, -1 means unknown size
+func readElementSize(r byteReader) (int64, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	length := vintLength(first)
+	if length > 8 {
+		return 0, fmt.Errorf("%w: invalid element size", ErrUnsupportedInput)
+	}
+
+	mask := byte(0xff >> length)
+	size := uint64(first & mask)
+	allOnes := first&mask == mask
+	for i := 1; i < length; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		size = size<<8 | uint64(b)
+		allOnes = allOnes && b == 0xff
+	}
+
+	if allOnes {
+		return -1, nil
+	}
+	return int64(size), nil
+}
+
+// vintLength returns the length of a variable size integer from its first byte
+func vintLength(first byte) int {
+	for length := 1; length <= 8; length++ {
+		if first&(0x80>>(length-1)) != 0 {
+			return length
+		}
+	}
+	return 9
+}
+
+// readElement reads the payload of an element, only used for the small ones
+func readElement(r io.Reader, size int64) ([]byte, error) {
+	const maxElement = 64 << 20
+	if size < 0 || size > maxElement {
+		return nil, fmt.Errorf("%w: element too big", ErrUnsupportedInput)
+	}
+
+	payload := make([]byte, size)
+	_, err := io.ReadFull(r, payload)
+	return payload, err
+}
+
+// opusPacketSamples returns the number of samples at 48 kHz in an Opus packet (RFC 6716, section 3.1)
+func opusPacketSamples(packet []byte) int64 {
+	if len(packet) == 0 {
+		return 0
+	}
+
+	toc := packet[0]
+	config := toc >> 3
+
+	// frame size in units of 2.5 ms (120 samples)
+	var units int64
+	switch {
+	case config < 12: // SILK: 10, 20, 40, 60 ms
+		units = []int64{4, 8, 16, 24}[config%4]
+	case config < 16: // Hybrid: 10, 20 ms
+		units = []int64{4, 8}[config%2]
+	default: // CELT: 2.5, 5, 10, 20 ms
+		units = []int64{1, 2, 4, 8}[config%4]
+	}
+
+	var frames int64
+	switch toc & 0x03 {
+	case 0:
+		frames = 1
+	case 1, 2:
+		frames = 2
+	default:
+		if len(packet) < 2 {
+			return 0
+		}
+		frames = int64(packet[1] & 0x3f)
+	}
+
+	return frames * units * 120
+}
+
+// opusTags builds the comment header with the tags as Vorbis comments
+func (t Tags) opusTags() []byte {
+	comments := []string{}
+	for _, c := range [][2]string{{"TITLE", t.Title}, {"ARTIST", t.Artist}, {"ALBUM", t.Album}} {
+		if c[1] != "" {
+			comments = append(comments, c[0]+"="+c[1])
+		}
+	}
+	if len(t.Cover) > 0 {
+		comments = append(comments, "METADATA_BLOCK_PICTURE="+base64.StdEncoding.EncodeToString(t.flacPicture()))
+	}
+
+	const vendor = "youtube"
+	var b bytes.Buffer
+	b.WriteString("OpusTags")
+	binary.Write(&b, binary.LittleEndian, uint32(len(vendor)))
+	b.WriteString(vendor)
+	binary.Write(&b, binary.LittleEndian, uint32(len(comments)))
+	for _, c := range comments {
+		binary.Write(&b, binary.LittleEndian, uint32(len(c)))
+		b.WriteString(c)
+	}
+	return b.Bytes()
+}
+
+// flacPicture builds a FLAC picture block with the cover as front cover
+func (t Tags) flacPicture() []byte {
+	const frontCover = 3
+	mimeType := t.coverMimeType()
+
+	return concat(
+		u32(frontCover),
+		u32(uint32(len(mimeType))), []byte(mimeType),
+		u32(0),                         // no description
+		u32(0), u32(0), u32(0), u32(0), // width, height, depth and colors are optional
+		u32(uint32(len(t.Cover))), t.Cover,
+	)
+}
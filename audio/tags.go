@@ -0,0 +1,35 @@
+// Package audio remuxes the audio-only streams of youtube into standalone files.
+// DASH fragmented MP4 audio becomes a regular .m4a file, WebM Opus becomes an Ogg Opus file.
+// Nothing is transcoded, the audio packets are copied as they are.
+// Tags like a cover can also be embedded into existing MP4 files.
+package audio
+
+import (
+	"net/http"
+)
+
+type constError string
+
+const (
+	ErrNoAudio          = constError("no audio samples found")
+	ErrUnsupportedInput = constError("unsupported input stream")
+)
+
+func (e constError) Error() string {
+	return string(e)
+}
+
+// Tags are the metadata written into the audio file
+type Tags struct {
+	Title  string
+	Artist string
+	Album  string
+
+	// Cover is a JPEG or PNG image
+	Cover []byte
+}
+
+// coverMimeType detects the type of the cover image
+func (t Tags) coverMimeType() string {
+	return http.DetectContentType(t.Cover)
+}
@@ -0,0 +1,193 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fragment builds a moof with the given sample sizes followed by its mdat
+func fragment(sizes []uint32, fill byte) []byte {
+	var data []byte
+	trunSizes := [][]byte{fullBox(0, 0x201), u32(uint32(len(sizes))), nil}
+	for _, size := range sizes {
+		trunSizes = append(trunSizes, u32(size))
+		data = append(data, bytes.Repeat([]byte{fill}, int(size))...)
+	}
+
+	build := func(dataOffset uint32) []byte {
+		trunSizes[2] = u32(dataOffset)
+		return makeBox("moof",
+			makeBox("mfhd", fullBox(0, 0), u32(1)),
+			makeBox("traf",
+				makeBox("tfhd", fullBox(0, 0x020000), u32(1)),
+				makeBox("trun", trunSizes...),
+			),
+		)
+	}
+
+	// the data starts behind moof and the mdat header
+	moof := build(0)
+	moof = build(uint32(len(moof) + 8))
+	return concat(moof, makeBox("mdat", data))
+}
+
+func TestRemuxM4A(t *testing.T) {
+	moov := makeBox("moov",
+		makeBox("mvhd", timeFields(1000, 0)),
+		makeBox("mvex", makeBox("trex", fullBox(0, 0), u32(1), u32(1), u32(1024), u32(0), u32(0))),
+		makeBox("trak", makeBox("mdia",
+			makeBox("mdhd", timeFields(44100, 0), u16(0x55c4), u16(0)),
+			makeBox("minf", makeBox("stbl", makeBox("stsd", fullBox(0, 0), u32(1), makeBox("mp4a")))),
+		)),
+	)
+	input := concat(makeBox("ftyp", []byte("dash")), moov, makeBox("sidx"), fragment([]uint32{3, 4}, 'a'), fragment([]uint32{5}, 'b'))
+
+	out, err := os.CreateTemp(t.TempDir(), "*.m4a")
+	require.NoError(t, err)
+	defer out.Close()
+
+	require.NoError(t, RemuxM4A(out, bytes.NewReader(input), Tags{Title: "title", Cover: []byte("\x89PNG\r\n\x1a\n")}))
+	result, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+
+	stbl := findBox(findBox(result, "moov"), "trak", "mdia", "minf", "stbl")
+	require.NotNil(t, stbl)
+
+	stsz := findBox(stbl, "stsz")
+	assert.Equal(t, concat(fullBox(0, 0), u32(0), u32(3), u32(3), u32(4), u32(5)), stsz)
+	assert.Equal(t, concat(fullBox(0, 0), u32(1), u32(3), u32(1024)), findBox(stbl, "stts"))
+
+	// the chunk offset points to the samples in mdat
+	offset := binary.BigEndian.Uint32(findBox(stbl, "stco")[8:])
+	assert.Equal(t, "aaaaaaabbbbb", string(result[offset:offset+12]))
+	assert.Equal(t, []byte("mp4a"), findBox(stbl, "stsd")[12:16])
+
+	mdhd := findBox(result, "moov", "trak", "mdia", "mdhd")
+	assert.Equal(t, uint32(44100), binary.BigEndian.Uint32(mdhd[12:]))
+	assert.Equal(t, uint32(3*1024), binary.BigEndian.Uint32(mdhd[16:]))
+
+	ilst := findBox(result, "moov", "udta", "meta")[4:]
+	assert.Equal(t, "title", string(findBox(ilst, "ilst", "\xa9nam", "data")[8:]))
+	assert.Equal(t, uint32(14), binary.BigEndian.Uint32(findBox(ilst, "ilst", "covr", "data")))
+}
+
+// element builds an EBML element with an 8 byte size
+func element(id uint32, parts ...[]byte) []byte {
+	payload := concat(parts...)
+	size := u64(uint64(len(payload)))
+	size[0] = 0x01
+
+	idBytes := u32(id)
+	for idBytes[0] == 0 {
+		idBytes = idBytes[1:]
+	}
+	return concat(idBytes, size, payload)
+}
+
+func TestRemuxOpus(t *testing.T) {
+	head := concat([]byte("OpusHead"), []byte{1, 2}, []byte{0x38, 0x01}, []byte{0x80, 0xbb, 0, 0}, []byte{0, 0, 0})
+	tracks := element(idTracks,
+		element(idTrackEntry, element(idTrackNumber, []byte{2}), element(idCodecID, []byte("V_VP9"))),
+		element(idTrackEntry, element(idTrackNumber, []byte{1}), element(idCodecID, []byte("A_OPUS")), element(idCodecPrivate, head)),
+	)
+
+	// 20 ms CELT frames of 300 bytes, so the packets need two lacing values
+	var blocks [][]byte
+	for i := 0; i < 30; i++ {
+		packet := append([]byte{31 << 3}, bytes.Repeat([]byte{byte(i)}, 299)...)
+		blocks = append(blocks, element(idSimpleBlock, []byte{0x81, 0, 0, 0x80}, packet))
+	}
+	blocks = append(blocks, element(idSimpleBlock, []byte{0x82, 0, 0, 0x80}, []byte("video")))
+
+	segment := concat(u32(idSegment), []byte{0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	input := concat(element(0x1A45DFA3, []byte{0x42, 0x82, 0x84, 'w', 'e', 'b', 'm'}), segment, tracks, element(idCluster, blocks...))
+
+	var out bytes.Buffer
+	require.NoError(t, RemuxOpus(&out, bytes.NewReader(input), Tags{Title: "title", Artist: "artist"}))
+
+	// read the pages back
+	var packets [][]byte
+	var lastGranule int64
+	var pending []byte
+	result := out.Bytes()
+	for page := 0; len(result) > 0; page++ {
+		require.Equal(t, "OggS", string(result[:4]))
+
+		segments := int(result[26])
+		size := 27 + segments
+		for _, lacing := range result[27 : 27+segments] {
+			size += int(lacing)
+		}
+
+		// the checksum is calculated with its own field set to zero
+		crc := binary.LittleEndian.Uint32(result[22:])
+		page := append([]byte{}, result[:size]...)
+		copy(page[22:26], []byte{0, 0, 0, 0})
+		var check uint32
+		for _, b := range page {
+			check = check<<8 ^ oggCRCTable[byte(check>>24)^b]
+		}
+		assert.Equal(t, crc, check)
+
+		data := result[27+segments : size]
+		for _, lacing := range result[27 : 27+segments] {
+			pending = append(pending, data[:lacing]...)
+			data = data[lacing:]
+			if lacing < 255 {
+				packets = append(packets, pending)
+				pending = nil
+			}
+		}
+
+		lastGranule = int64(binary.LittleEndian.Uint64(result[6:]))
+		if len(result) == size {
+			assert.Equal(t, byte(0x04), result[5]&0x04, "last page must be EOS")
+		}
+		result = result[size:]
+	}
+
+	require.Len(t, packets, 32)
+	assert.Equal(t, head, packets[0])
+	assert.Contains(t, string(packets[1]), "TITLE=title")
+	assert.Contains(t, string(packets[1]), "ARTIST=artist")
+	assert.Equal(t, int64(30*960), lastGranule)
+	assert.Len(t, packets[31], 300)
+}
+
+func TestEmbedTags(t *testing.T) {
+	// moov with an old title in front of the media data, like progressive YouTube MP4s
+	build := func(offset uint32) []byte {
+		return makeBox("moov",
+			makeBox("mvhd", timeFields(1000, 0)),
+			makeBox("trak", makeBox("mdia", makeBox("minf", makeBox("stbl", makeBox("stco", fullBox(0, 0), u32(1), u32(offset)))))),
+			Tags{Title: "old"}.udta(),
+		)
+	}
+	ftyp := makeBox("ftyp", []byte("isom"))
+	moov := build(0)
+	moov = build(uint32(len(ftyp) + len(moov) + 8))
+	input := concat(ftyp, moov, makeBox("mdat", []byte("samples")))
+
+	var out bytes.Buffer
+	cover := []byte("\xff\xd8\xff\xe0jpeg")
+	require.NoError(t, EmbedTags(&out, bytes.NewReader(input), Tags{Title: "new", Cover: cover}))
+	result := out.Bytes()
+
+	offset := binary.BigEndian.Uint32(findBox(result, "moov", "trak", "mdia", "minf", "stbl", "stco")[8:])
+	assert.Equal(t, "samples", string(result[offset:offset+7]))
+
+	ilst := findBox(result, "moov", "udta", "meta")[4:]
+	assert.Equal(t, "new", string(findBox(ilst, "ilst", "\xa9nam", "data")[8:]))
+	assert.Equal(t, cover, findBox(ilst, "ilst", "covr", "data")[8:])
+
+	// moov behind the media data keeps the offsets
+	input = concat(ftyp, makeBox("mdat", []byte("samples")), build(uint32(len(ftyp)+8)))
+	out.Reset()
+	require.NoError(t, EmbedTags(&out, bytes.NewReader(input), Tags{Title: "new", Cover: cover}))
+	assert.Equal(t, u32(uint32(len(ftyp)+8)), findBox(out.Bytes(), "moov", "trak", "mdia", "minf", "stbl", "stco")[8:])
+}
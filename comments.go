@@ -0,0 +1,427 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CommentSort is the order of the comments
+type CommentSort string
+
+const (
+	CommentSortTop    CommentSort = "top"
+	CommentSortNewest CommentSort = "newest"
+)
+
+// CommentOptions are the options of GetComments, the zero value fetches top comments without replies
+type CommentOptions struct {
+	Sort CommentSort
+	// Replies fetches the replies of every comment, they follow the comment they belong to
+	Replies bool
+}
+
+// Comment is a comment or a reply to one
+type Comment struct {
+	ID string `json:"id"`
+	// ParentID is the ID of the comment a reply belongs to, it's empty for comments
+	ParentID        string `json:"parentId,omitempty"`
+	Text            string `json:"text"`
+	Author          string `json:"author"`
+	AuthorChannelID string `json:"authorChannelId"`
+	Likes           int    `json:"likes"`
+	// ReplyCount is the number of replies of comments
+	ReplyCount int  `json:"replyCount"`
+	Pinned     bool `json:"pinned"`
+	// Hearted is set when the creator of the video liked the comment
+	Hearted bool `json:"hearted"`
+	Edited  bool `json:"edited"`
+	// Published is the relative time as YouTube shows it, like "2 days ago"
+	Published string `json:"published"`
+	// PublishedAt is Published subtracted from the time the comment was fetched, YouTube rounds it
+	PublishedAt time.Time `json:"publishedAt"`
+}
+
+// CommentIterator steps through the comments of a video, a page at a time:
+//
+//	it := client.GetComments(ctx, id, nil)
+//	for it.Next() {
+//		fmt.Println(it.Comment().Text)
+//	}
+//	return it.Err()
+type CommentIterator struct {
+	client  *Client
+	ctx     context.Context
+	videoID string
+	opts    CommentOptions
+
+	started bool
+	// continuation is the token of the next page of comments
+	continuation string
+	// queue is the rest of the current page, replies are fetched when their comment is reached
+	queue   []queuedComment
+	comment Comment
+	err     error
+}
+
+// queuedComment is a comment, or the token of more replies when comment is nil
+type queuedComment struct {
+	comment  *Comment
+	replies  string
+	parentID string
+}
+
+// GetComments returns an iterator over the comments of the video, opts may be nil.
+// Only one page of comments is held at a time, no request is made before Next is called.
+func (c *Client) GetComments(ctx context.Context, videoID string, opts *CommentOptions) *CommentIterator {
+	it := &CommentIterator{client: c, ctx: ctx}
+	if opts != nil {
+		it.opts = *opts
+	}
+
+	id, err := ExtractVideoID(videoID)
+	if err != nil {
+		it.err = fmt.Errorf("extractVideoID failed: %w", err)
+	}
+	it.videoID = id
+
+	switch it.opts.Sort {
+	case "", CommentSortTop, CommentSortNewest:
+	default:
+		it.err = fmt.Errorf("unknown comment sort %q", it.opts.Sort)
+	}
+	return it
+}
+
+// Next advances to the next comment, it returns false at the end or on errors
+func (it *CommentIterator) Next() bool {
+	for it.err == nil {
+		if len(it.queue) == 0 {
+			switch {
+			case !it.started:
+				it.started = true
+				it.err = it.start()
+			case it.continuation != "":
+				it.err = it.fetchPage(it.continuation, "")
+			default:
+				return false
+			}
+			continue
+		}
+
+		item := it.queue[0]
+		it.queue = it.queue[1:]
+
+		if item.replies != "" && it.opts.Replies {
+			if it.err = it.fetchPage(item.replies, item.parentID); it.err != nil {
+				return false
+			}
+		}
+		if item.comment != nil {
+			it.comment = *item.comment
+			return true
+		}
+	}
+	return false
+}
+
+// Comment returns the current comment
+func (it *CommentIterator) Comment() Comment {
+	return it.comment
+}
+
+// Err returns the error that stopped the iteration
+func (it *CommentIterator) Err() error {
+	return it.err
+}
+
+// start finds the comment section of the watch page and switches to the newest first if wanted
+func (it *CommentIterator) start() error {
+	body, err := it.client.next(it.ctx, innertubeNextRequest{VideoID: it.videoID})
+	if err != nil {
+		return err
+	}
+
+	var data watchNextResponseData
+	if err = json.Unmarshal(body, &data); err != nil {
+		return fmt.Errorf("unable to parse next response JSON: %w", err)
+	}
+
+	token := data.commentsContinuation()
+	if token == "" {
+		return ErrCommentsDisabled
+	}
+
+	page, err := it.fetch(token)
+	if err != nil {
+		return err
+	}
+
+	if it.opts.Sort == CommentSortNewest {
+		token = page.sortToken(1)
+		if token == "" {
+			return fmt.Errorf("sort menu of comments not found")
+		}
+		if page, err = it.fetch(token); err != nil {
+			return err
+		}
+	}
+
+	it.add(page.items(), "")
+	return nil
+}
+
+// fetchPage fetches the next page of comments or of the replies to parentID
+func (it *CommentIterator) fetchPage(token, parentID string) error {
+	page, err := it.fetch(token)
+	if err != nil {
+		return err
+	}
+
+	if parentID == "" {
+		it.continuation = ""
+		it.add(page.items(), "")
+		return nil
+	}
+
+	// replies go in front of the rest of the page
+	rest := it.queue
+	it.queue = nil
+	it.add(page.items(), parentID)
+	it.queue = append(it.queue, rest...)
+	return nil
+}
+
+func (it *CommentIterator) fetch(token string) (*commentsResponseData, error) {
+	body, err := it.client.next(it.ctx, innertubeNextRequest{Continuation: token})
+	if err != nil {
+		return nil, err
+	}
+
+	var page commentsResponseData
+	if err = json.Unmarshal(body, &page); err != nil {
+		return nil, fmt.Errorf("unable to parse comments response JSON: %w", err)
+	}
+	return &page, nil
+}
+
+// add queues the items of a page, the continuation of top level items is the next page
+func (it *CommentIterator) add(items []commentItem, parentID string) {
+	now := time.Now()
+	for _, item := range items {
+		switch {
+		case item.CommentThreadRenderer != nil:
+			thread := item.CommentThreadRenderer
+			comment := thread.Comment.CommentRenderer.comment(now)
+			it.queue = append(it.queue, queuedComment{
+				comment:  &comment,
+				replies:  continuationToken(thread.Replies.CommentRepliesRenderer.Contents),
+				parentID: comment.ID,
+			})
+
+		case item.CommentRenderer != nil:
+			comment := item.CommentRenderer.comment(now)
+			comment.ParentID = parentID
+			it.queue = append(it.queue, queuedComment{comment: &comment})
+
+		case item.ContinuationItemRenderer != nil:
+			if token := item.ContinuationItemRenderer.token(); parentID != "" {
+				it.queue = append(it.queue, queuedComment{replies: token, parentID: parentID})
+			} else {
+				it.continuation = token
+			}
+		}
+	}
+}
+
+// commentsContinuation returns the token of the comment section, it's empty when comments are off
+func (data *watchNextResponseData) commentsContinuation() string {
+	for _, content := range data.Contents.TwoColumnWatchNextResults.Results.Results.Contents {
+		section := content.ItemSectionRenderer
+		if section == nil || section.SectionIdentifier != "comment-item-section" {
+			continue
+		}
+		for _, item := range section.Contents {
+			if item.ContinuationItemRenderer != nil {
+				return item.ContinuationItemRenderer.token()
+			}
+		}
+	}
+	return ""
+}
+
+// commentsResponseData presents the parts of a page of comments or replies
+type commentsResponseData struct {
+	OnResponseReceivedEndpoints []struct {
+		ReloadContinuationItemsCommand *struct {
+			ContinuationItems []commentItem `json:"continuationItems"`
+		} `json:"reloadContinuationItemsCommand"`
+		AppendContinuationItemsAction *struct {
+			ContinuationItems []commentItem `json:"continuationItems"`
+		} `json:"appendContinuationItemsAction"`
+	} `json:"onResponseReceivedEndpoints"`
+}
+
+type commentItem struct {
+	CommentsHeaderRenderer *struct {
+		SortMenu struct {
+			SortFilterSubMenuRenderer struct {
+				SubMenuItems []struct {
+					Title           string `json:"title"`
+					ServiceEndpoint struct {
+						ContinuationCommand struct {
+							Token string `json:"token"`
+						} `json:"continuationCommand"`
+					} `json:"serviceEndpoint"`
+				} `json:"subMenuItems"`
+			} `json:"sortFilterSubMenuRenderer"`
+		} `json:"sortMenu"`
+	} `json:"commentsHeaderRenderer"`
+	CommentThreadRenderer *struct {
+		Comment struct {
+			CommentRenderer commentRenderer `json:"commentRenderer"`
+		} `json:"comment"`
+		Replies struct {
+			CommentRepliesRenderer struct {
+				Contents []commentItem `json:"contents"`
+			} `json:"commentRepliesRenderer"`
+		} `json:"replies"`
+	} `json:"commentThreadRenderer"`
+	CommentRenderer          *commentRenderer          `json:"commentRenderer"`
+	ContinuationItemRenderer *continuationItemRenderer `json:"continuationItemRenderer"`
+}
+
+type commentRenderer struct {
+	CommentID      string        `json:"commentId"`
+	ContentText    innertubeText `json:"contentText"`
+	AuthorText     innertubeText `json:"authorText"`
+	AuthorEndpoint struct {
+		BrowseEndpoint struct {
+			BrowseID string `json:"browseId"`
+		} `json:"browseEndpoint"`
+	} `json:"authorEndpoint"`
+	PublishedTimeText  innertubeText    `json:"publishedTimeText"`
+	VoteCount          innertubeText    `json:"voteCount"`
+	ReplyCount         int              `json:"replyCount"`
+	PinnedCommentBadge *json.RawMessage `json:"pinnedCommentBadge"`
+	ActionButtons      struct {
+		CommentActionButtonsRenderer struct {
+			CreatorHeart struct {
+				CreatorHeartRenderer struct {
+					IsHearted bool `json:"isHearted"`
+				} `json:"creatorHeartRenderer"`
+			} `json:"creatorHeart"`
+		} `json:"commentActionButtonsRenderer"`
+	} `json:"actionButtons"`
+}
+
+func (r *commentRenderer) comment(now time.Time) Comment {
+	published := r.PublishedTimeText.String()
+	edited := strings.HasSuffix(published, "(edited)")
+	published = strings.TrimSpace(strings.TrimSuffix(published, "(edited)"))
+
+	return Comment{
+		ID:              r.CommentID,
+		Text:            r.ContentText.String(),
+		Author:          r.AuthorText.String(),
+		AuthorChannelID: r.AuthorEndpoint.BrowseEndpoint.BrowseID,
+		Likes:           int(parseShortCount(r.VoteCount.String())),
+		ReplyCount:      r.ReplyCount,
+		Pinned:          r.PinnedCommentBadge != nil,
+		Hearted:         r.ActionButtons.CommentActionButtonsRenderer.CreatorHeart.CreatorHeartRenderer.IsHearted,
+		Edited:          edited,
+		Published:       published,
+		PublishedAt:     now.Add(-parseTimeAgo(published)),
+	}
+}
+
+func (data *commentsResponseData) items() []commentItem {
+	var items []commentItem
+	for _, endpoint := range data.OnResponseReceivedEndpoints {
+		if endpoint.ReloadContinuationItemsCommand != nil {
+			items = append(items, endpoint.ReloadContinuationItemsCommand.ContinuationItems...)
+		}
+		if endpoint.AppendContinuationItemsAction != nil {
+			items = append(items, endpoint.AppendContinuationItemsAction.ContinuationItems...)
+		}
+	}
+	return items
+}
+
+// sortToken returns the token of an entry of the sort menu, 0 is top and 1 is newest
+func (data *commentsResponseData) sortToken(i int) string {
+	for _, item := range data.items() {
+		if item.CommentsHeaderRenderer == nil {
+			continue
+		}
+		entries := item.CommentsHeaderRenderer.SortMenu.SortFilterSubMenuRenderer.SubMenuItems
+		if i < len(entries) {
+			return entries[i].ServiceEndpoint.ContinuationCommand.Token
+		}
+	}
+	return ""
+}
+
+// continuationToken returns the token of the first continuation in the items
+func continuationToken(items []commentItem) string {
+	for _, item := range items {
+		if item.ContinuationItemRenderer != nil {
+			return item.ContinuationItemRenderer.token()
+		}
+	}
+	return ""
+}
+
+// parseShortCount parses counts like 15, 1.2K and 3M
+func parseShortCount(value string) int64 {
+	value = strings.TrimSpace(strings.ReplaceAll(value, ",", ""))
+	if value == "" {
+		return 0
+	}
+
+	multiplier := 1.0
+	switch value[len(value)-1] {
+	case 'K':
+		multiplier = 1e3
+	case 'M':
+		multiplier = 1e6
+	case 'B':
+		multiplier = 1e9
+	}
+	if multiplier > 1 {
+		value = value[:len(value)-1]
+	}
+
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return int64(n*multiplier + 0.5)
+}
+
+var timeAgoUnits = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+	"month":  30 * 24 * time.Hour,
+	"year":   365 * 24 * time.Hour,
+}
+
+// parseTimeAgo parses relative times like "1 day ago" and "3 weeks ago", unknown texts are 0
+func parseTimeAgo(value string) time.Duration {
+	fields := strings.Fields(value)
+	if len(fields) < 2 {
+		return 0
+	}
+
+	n, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0
+	}
+	return time.Duration(n) * timeAgoUnits[strings.TrimSuffix(fields[1], "s")]
+}
@@ -1,207 +1,381 @@
-package youtube
-
-import (
-	"bytes"
-	"context"
-	"errors"
-	"fmt"
-	"net/url"
-	"regexp"
-	"strconv"
-
-	"github.com/dop251/goja"
-)
-
-func (c *Client) decipherURL(ctx context.Context, videoID string, cipher string) (string, error) {
-	params, err := url.ParseQuery(cipher)
-	if err != nil {
-		return "", err
-	}
-
-	uri, err := url.Parse(params.Get("url"))
-	if err != nil {
-		return "", err
-	}
-	query := uri.Query()
-
-	config, err := c.getPlayerConfig(ctx, videoID)
-	if err != nil {
-		return "", err
-	}
-
-	// decrypt s-parameter
-	bs, err := config.decrypt([]byte(params.Get("s")))
-	if err != nil {
-		return "", err
-	}
-	query.Add(params.Get("sp"), string(bs))
-
-	// decrypt n-parameter
-	nSig := query.Get("n")
-	if nSig != "" {
-		nDecoded, err := config.decodeNsig(nSig)
-		if err != nil {
-			return "", fmt.Errorf("unable to decode nSig: %w", err)
-		}
-		query.Set("n", nDecoded)
-	}
-
-	uri.RawQuery = query.Encode()
-
-	return uri.String(), nil
-}
-
-const (
-	jsvarStr   = "[a-zA-Z_\\$][a-zA-Z_0-9]*"
-	reverseStr = ":function\\(a\\)\\{" +
-		"(?:return )?a\\.reverse\\(\\)" +
-		"\\}"
-	spliceStr = ":function\\(a,b\\)\\{" +
-		"a\\.splice\\(0,b\\)" +
-		"\\}"
-	swapStr = ":function\\(a,b\\)\\{" +
-		"var c=a\\[0\\];a\\[0\\]=a\\[b(?:%a\\.length)?\\];a\\[b(?:%a\\.length)?\\]=c(?:;return a)?" +
-		"\\}"
-)
-
-var (
-	nFunctionNameRegexp = regexp.MustCompile("\\.get\\(\"n\"\\)\\)&&\\(b=([a-zA-Z0-9]+)\\(b\\)")
-	actionsObjRegexp    = regexp.MustCompile(fmt.Sprintf(
-		"var (%s)=\\{((?:(?:%s%s|%s%s|%s%s),?\\n?)+)\\};", jsvarStr, jsvarStr, swapStr, jsvarStr, spliceStr, jsvarStr, reverseStr))
-
-	actionsFuncRegexp = regexp.MustCompile(fmt.Sprintf(
-		"function(?: %s)?\\(a\\)\\{"+
-			"a=a\\.split\\(\"\"\\);\\s*"+
-			"((?:(?:a=)?%s\\.%s\\(a,\\d+\\);)+)"+
-			"return a\\.join\\(\"\"\\)"+
-			"\\}", jsvarStr, jsvarStr, jsvarStr))
-
-	reverseRegexp = regexp.MustCompile(fmt.Sprintf("(?m)(?:^|,)(%s)%s", jsvarStr, reverseStr))
-	spliceRegexp  = regexp.MustCompile(fmt.Sprintf("(?m)(?:^|,)(%s)%s", jsvarStr, spliceStr))
-	swapRegexp    = regexp.MustCompile(fmt.Sprintf("(?m)(?:^|,)(%s)%s", jsvarStr, swapStr))
-)
-
-func (config playerConfig) decodeNsig(encoded string) (string, error) {
-	fBody, err := config.getNFunction()
-	if err != nil {
-		return "", err
-	}
-
-	return evalJavascript(fBody, encoded)
-}
-
-func evalJavascript(jsFunction, arg string) (string, error) {
-	const myName = "myFunction"
-
-	vm := goja.New()
-	_, err := vm.RunString(myName + "=" + jsFunction)
-	if err != nil {
-		return "", err
-	}
-
-	var output func(string) string
-	err = vm.ExportTo(vm.Get(myName), &output)
-	if err != nil {
-		return "", err
-	}
-
-	return output(arg), nil
-}
-
-func (config playerConfig) getNFunction() (string, error) {
-	nameResult := nFunctionNameRegexp.FindSubmatch(config)
-	if len(nameResult) == 0 {
-		return "", errors.New("unable to extract n-function name")
-	}
-	name := string(nameResult[1])
-
-	// find the beginning of the function
-	def := []byte(name + "=function(")
-	start := bytes.Index(config, def)
-	if start < 1 {
-		return "", fmt.Errorf("unable to extract n-function body: looking for '%s'", def)
-	}
-
-	// start after the first curly bracket
-	pos := start + bytes.IndexByte(config[start:], '{') + 1
-
-	// find the bracket closing the function
-	for brackets := 1; brackets > 0; pos++ {
-		switch config[pos] {
-		case '{':
-			brackets++
-		case '}':
-			brackets--
-		}
-	}
-
-	return string(config[start:pos]), nil
-}
-
-func (config playerConfig) decrypt(cyphertext []byte) ([]byte, error) {
-	operations, err := config.parseDecipherOps()
-	if err != nil {
-		return nil, err
-	}
-
-	// apply operations
-	bs := []byte(cyphertext)
-	for _, op := range operations {
-		bs = op(bs)
-	}
-
-	return bs, nil
-}
-
-func (config playerConfig) parseDecipherOps() (operations []DecipherOperation, err error) {
-	objResult := actionsObjRegexp.FindSubmatch(config)
-	funcResult := actionsFuncRegexp.FindSubmatch(config)
-	if len(objResult) < 3 || len(funcResult) < 2 {
-		return nil, fmt.Errorf("error parsing signature tokens (#obj=%d, #func=%d)", len(objResult), len(funcResult))
-	}
-
-	obj := objResult[1]
-	objBody := objResult[2]
-	funcBody := funcResult[1]
-
-	var reverseKey, spliceKey, swapKey string
-
-	if result := reverseRegexp.FindSubmatch(objBody); len(result) > 1 {
-		reverseKey = string(result[1])
-	}
-	if result := spliceRegexp.FindSubmatch(objBody); len(result) > 1 {
-		spliceKey = string(result[1])
-	}
-	if result := swapRegexp.FindSubmatch(objBody); len(result) > 1 {
-		swapKey = string(result[1])
-	}
-
-	regex, err := regexp.Compile(fmt.Sprintf("(?:a=)?%s\\.(%s|%s|%s)\\(a,(\\d+)\\)", obj, reverseKey, spliceKey, swapKey))
-	if err != nil {
-		return nil, err
-	}
-
-	var ops []DecipherOperation
-	for _, s := range regex.FindAllSubmatch(funcBody, -1) {
-		switch string(s[1]) {
-		case reverseKey:
-			ops = append(ops, reverseFunc)
-		case swapKey:
-			arg, _ := strconv.Atoi(string(s[2]))
-			ops = append(ops, newSwapFunc(arg))
-		case spliceKey:
-			arg, _ := strconv.Atoi(string(s[2]))
-			ops = append(ops, newSpliceFunc(arg))
-		}
-	}
-	return ops, nil
-}
-
-func (config playerConfig) getSignatureTimestamp() (string, error) {
-	result := signatureRegexp.FindSubmatch(config)
-	if result == nil {
-		return "", ErrSignatureTimestampNotFound
-	}
-
-	return string(result[1]), nil
-}
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+func (c *Client) decipherURL(ctx context.Context, videoID string, cipher string) (string, error) {
+	params, err := url.ParseQuery(cipher)
+	if err != nil {
+		return "", err
+	}
+
+	uri, err := url.Parse(params.Get("url"))
+	if err != nil {
+		return "", err
+	}
+	query := uri.Query()
+
+	config, functions, err := c.getPlayerConfig(ctx, videoID)
+	if err != nil {
+		return "", err
+	}
+
+	// decrypt s-parameter
+	bs, err := config.decrypt(ctx, []byte(params.Get("s")), functions)
+	if err != nil {
+		return "", err
+	}
+	query.Add(params.Get("sp"), string(bs))
+
+	// decrypt n-parameter
+	if err = config.decodeNParam(ctx, query, functions); err != nil {
+		return "", err
+	}
+
+	uri.RawQuery = query.Encode()
+
+	return uri.String(), nil
+}
+
+// unThrottleURL decodes the n-parameter of a stream URL which is not ciphered
+func (c *Client) unThrottleURL(ctx context.Context, videoID string, rawURL string) (string, error) {
+	uri, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	query := uri.Query()
+
+	// without n-parameter there is nothing to do, no need to fetch the player
+	if query.Get("n") == "" {
+		return rawURL, nil
+	}
+
+	config, functions, err := c.getPlayerConfig(ctx, videoID)
+	if err != nil {
+		return "", err
+	}
+
+	if err = config.decodeNParam(ctx, query, functions); err != nil {
+		return "", err
+	}
+
+	uri.RawQuery = query.Encode()
+
+	return uri.String(), nil
+}
+
+// decodeNParam replaces the n-parameter of query with its decoded value
+func (config playerConfig) decodeNParam(ctx context.Context, query url.Values, functions *extractedFunctions) error {
+	nSig := query.Get("n")
+	if nSig == "" {
+		return nil
+	}
+
+	nDecoded, err := config.decodeNsig(ctx, nSig, functions)
+	if err != nil {
+		return fmt.Errorf("unable to decode nSig: %w", err)
+	}
+	query.Set("n", nDecoded)
+
+	return nil
+}
+
+const (
+	jsvarStr   = "[a-zA-Z_\\$][a-zA-Z_0-9\\$]*"
+	reverseStr = ":function\\(a\\)\\{" +
+		"(?:return )?a\\.reverse\\(\\)" +
+		"\\}"
+	spliceStr = ":function\\(a,b\\)\\{" +
+		"a\\.splice\\(0,b\\)" +
+		"\\}"
+	swapStr = ":function\\(a,b\\)\\{" +
+		"var c=a\\[0\\];a\\[0\\]=a\\[b(?:%a\\.length)?\\];a\\[b(?:%a\\.length)?\\]=c(?:;return a)?" +
+		"\\}"
+)
+
+// nFunctionFailedPrefix starts the result of the n-function when it failed
+const nFunctionFailedPrefix = "enhanced_except_"
+
+var (
+	nFunctionNameRegexp = regexp.MustCompile("\\.get\\(\"n\"\\)\\)&&\\(b=([a-zA-Z0-9_\\$]+)\\(b\\)")
+	actionsObjRegexp    = regexp.MustCompile(fmt.Sprintf(
+		"var (%s)=\\{((?:(?:%s%s|%s%s|%s%s),?\\n?)+)\\};", jsvarStr, jsvarStr, swapStr, jsvarStr, spliceStr, jsvarStr, reverseStr))
+
+	actionsFuncRegexp = regexp.MustCompile(fmt.Sprintf(
+		"function(?: %s)?\\(a\\)\\{"+
+			"a=a\\.split\\(\"\"\\);\\s*"+
+			"((?:(?:a=)?%s\\.%s\\(a,\\d+\\);)+)"+
+			"return a\\.join\\(\"\"\\)"+
+			"\\}", jsvarStr, jsvarStr, jsvarStr))
+
+	signatureFunctionRegexp = regexp.MustCompile(fmt.Sprintf("function(?: %s)?\\(a\\)\\{a=a\\.split\\(\"\"\\)", jsvarStr))
+
+	reverseRegexp = regexp.MustCompile(fmt.Sprintf("(?m)(?:^|,)(%s)%s", jsvarStr, reverseStr))
+	spliceRegexp  = regexp.MustCompile(fmt.Sprintf("(?m)(?:^|,)(%s)%s", jsvarStr, spliceStr))
+	swapRegexp    = regexp.MustCompile(fmt.Sprintf("(?m)(?:^|,)(%s)%s", jsvarStr, swapStr))
+)
+
+func (config playerConfig) decodeNsig(ctx context.Context, encoded string, functions *extractedFunctions) (string, error) {
+	fBody, err := config.getNFunction(functions)
+	if err != nil {
+		return "", err
+	}
+
+	decoded, err := evalJavascript(ctx, fBody, encoded)
+	if err != nil {
+		return "", err
+	}
+
+	// the player catches its own exceptions and returns them instead
+	if strings.HasPrefix(decoded, nFunctionFailedPrefix) {
+		return "", fmt.Errorf("%w: %s", ErrNFunctionFailed, decoded)
+	}
+	return decoded, nil
+}
+
+// extractedFunctions caches the functions extracted from a player by name, it's kept beside the player in the playerCache.
+// Extracting scans the whole player, which takes long for the megabytes of a real one.
+// A nil *extractedFunctions extracts on every call.
+type extractedFunctions struct {
+	mu        sync.Mutex
+	functions map[string]extractedFunction
+}
+
+type extractedFunction struct {
+	source string
+	err    error
+}
+
+// get returns the function cached under name, extract is only called on a miss
+func (f *extractedFunctions) get(name string, extract func() (string, error)) (string, error) {
+	if f == nil {
+		return extract()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if cached, ok := f.functions[name]; ok {
+		return cached.source, cached.err
+	}
+
+	// a player doesn't change, neither does the outcome of extracting from it
+	source, err := extract()
+	if f.functions == nil {
+		f.functions = make(map[string]extractedFunction)
+	}
+	f.functions[name] = extractedFunction{source: source, err: err}
+	return source, err
+}
+
+// getNFunction returns the n-function as standalone expression, it's extracted once per player
+func (config playerConfig) getNFunction(functions *extractedFunctions) (string, error) {
+	return functions.get("n", config.extractNFunction)
+}
+
+func (config playerConfig) extractNFunction() (string, error) {
+	nameResult := nFunctionNameRegexp.FindSubmatch(config)
+	if len(nameResult) == 0 {
+		return "", errors.New("unable to extract n-function name")
+	}
+	name := string(nameResult[1])
+
+	// find the beginning of the function
+	def := []byte(name + "=function(")
+	start := bytes.Index(config, def)
+	if start < 0 {
+		return "", fmt.Errorf("unable to extract n-function body: looking for '%s'", def)
+	}
+
+	fn, err := config.standaloneFunction(start + len(name) + 1)
+	if err != nil {
+		return "", fmt.Errorf("unable to extract n-function body: %w", err)
+	}
+	return fn, nil
+}
+
+func (config playerConfig) decrypt(ctx context.Context, cyphertext []byte, functions *extractedFunctions) ([]byte, error) {
+	operations, err := config.parseDecipherOps()
+	if err != nil {
+		// helpers the regular expressions don't know, let the player do it
+		output, jsErr := config.decryptJavascript(ctx, cyphertext, functions)
+		if jsErr != nil {
+			return nil, fmt.Errorf("%w, JavaScript fallback: %v", err, jsErr)
+		}
+		return output, nil
+	}
+
+	// apply operations
+	bs := []byte(cyphertext)
+	for _, op := range operations {
+		bs = op(bs)
+	}
+
+	return bs, nil
+}
+
+func (config playerConfig) parseDecipherOps() (operations []DecipherOperation, err error) {
+	objResult := actionsObjRegexp.FindSubmatch(config)
+	funcResult := actionsFuncRegexp.FindSubmatch(config)
+	if len(objResult) < 3 || len(funcResult) < 2 {
+		return nil, fmt.Errorf("error parsing signature tokens (#obj=%d, #func=%d)", len(objResult), len(funcResult))
+	}
+
+	obj := objResult[1]
+	objBody := objResult[2]
+	funcBody := funcResult[1]
+
+	var reverseKey, spliceKey, swapKey string
+
+	if result := reverseRegexp.FindSubmatch(objBody); len(result) > 1 {
+		reverseKey = string(result[1])
+	}
+	if result := spliceRegexp.FindSubmatch(objBody); len(result) > 1 {
+		spliceKey = string(result[1])
+	}
+	if result := swapRegexp.FindSubmatch(objBody); len(result) > 1 {
+		swapKey = string(result[1])
+	}
+
+	// names may contain a $
+	regex, err := regexp.Compile(fmt.Sprintf("(?:a=)?%s\\.(%s|%s|%s)\\(a,(\\d+)\\)",
+		regexp.QuoteMeta(string(obj)), regexp.QuoteMeta(reverseKey), regexp.QuoteMeta(spliceKey), regexp.QuoteMeta(swapKey)))
+	if err != nil {
+		return nil, err
+	}
+
+	// every call must be one of the known helpers
+	calls := len(regexp.MustCompile(fmt.Sprintf("%s\\.%s\\(a,", regexp.QuoteMeta(string(obj)), jsvarStr)).FindAll(funcBody, -1))
+	matches := regex.FindAllSubmatch(funcBody, -1)
+	if len(matches) != calls {
+		return nil, fmt.Errorf("unknown helpers in signature function (%d of %d calls known)", len(matches), calls)
+	}
+
+	var ops []DecipherOperation
+	for _, s := range matches {
+		switch string(s[1]) {
+		case reverseKey:
+			ops = append(ops, reverseFunc)
+		case swapKey:
+			arg, _ := strconv.Atoi(string(s[2]))
+			ops = append(ops, newSwapFunc(arg))
+		case spliceKey:
+			arg, _ := strconv.Atoi(string(s[2]))
+			ops = append(ops, newSpliceFunc(arg))
+		}
+	}
+	return ops, nil
+}
+
+// decryptJavascript runs the signature function of the player with its helper objects
+func (config playerConfig) decryptJavascript(ctx context.Context, cyphertext []byte, functions *extractedFunctions) ([]byte, error) {
+	fn, err := config.getSignatureFunction(functions)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := evalJavascript(ctx, fn, string(cyphertext))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(output), nil
+}
+
+// getSignatureFunction returns the signature function with the helper objects it calls, it's extracted once per player
+func (config playerConfig) getSignatureFunction(functions *extractedFunctions) (string, error) {
+	return functions.get("signature", config.extractSignatureFunction)
+}
+
+func (config playerConfig) extractSignatureFunction() (string, error) {
+	loc := signatureFunctionRegexp.FindIndex(config)
+	if loc == nil {
+		return "", errors.New("unable to find signature function")
+	}
+
+	fn, err := config.standaloneFunction(loc[0])
+	if err != nil {
+		return "", fmt.Errorf("unable to extract signature function: %w", err)
+	}
+	return fn, nil
+}
+
+// standaloneFunction extracts the function starting with the function keyword at start, together with
+// the variables and functions of the player it depends on, as an expression which runs on its own
+func (config playerConfig) standaloneFunction(start int) (string, error) {
+	end, err := functionEnd(config, start)
+	if err != nil {
+		return "", err
+	}
+	fn := config[start:end]
+
+	defs, depth, err := jsDefinitions(config, start)
+	if err != nil {
+		return "", err
+	}
+
+	var names []string
+	values := make(map[string][]byte)
+	for queue := [][]byte{fn}; len(queue) > 0; queue = queue[1:] {
+		free, err := freeIdentifiers(queue[0])
+		if err != nil {
+			return "", err
+		}
+
+		for _, name := range free {
+			if _, ok := values[name]; ok {
+				continue
+			}
+			def, ok := visibleDefinition(defs[name], depth)
+			if !ok {
+				// a global of the browser, calling it fails in goja
+				continue
+			}
+
+			value, err := def.source(config)
+			if err != nil {
+				return "", fmt.Errorf("unable to extract %s: %w", name, err)
+			}
+			names = append(names, name)
+			values[name] = value
+			queue = append(queue, value)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("(function(){")
+	// dependencies first, a value the function doesn't need must not stop it
+	for i := len(names) - 1; i >= 0; i-- {
+		fmt.Fprintf(&b, "var %s;try{%s=%s}catch(e){}", names[i], names[i], values[names[i]])
+	}
+	fmt.Fprintf(&b, "return %s})()", fn)
+	return b.String(), nil
+}
+
+// visibleDefinition picks the innermost definition which is not nested deeper than depth
+func visibleDefinition(defs []jsDefinition, depth int) (jsDefinition, bool) {
+	found, ok := jsDefinition{}, false
+	for _, def := range defs {
+		if def.depth <= depth && (!ok || def.depth > found.depth) {
+			found, ok = def, true
+		}
+	}
+	return found, ok
+}
+
+func (config playerConfig) getSignatureTimestamp() (string, error) {
+	result := signatureRegexp.FindSubmatch(config)
+	if result == nil {
+		return "", ErrSignatureTimestampNotFound
+	}
+
+	return string(result[1]), nil
+}
@@ -0,0 +1,132 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// PlayerCase is an input of a player function with the output the player computes for it
+type PlayerCase struct {
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+// PlayerCases are the known results of the signature and n-parameter functions of a player
+type PlayerCases struct {
+	Signature []PlayerCase `json:"signature"`
+	N         []PlayerCase `json:"n"`
+}
+
+// PlayerCheckStep is the result of one step of extracting the functions from a player
+type PlayerCheckStep struct {
+	Name   string
+	Detail string
+	Err    error
+
+	// Optional is set for the steps of the operations, the JavaScript fallback works without them
+	Optional bool
+}
+
+// playerCheck collects the steps of CheckPlayer
+type playerCheck struct {
+	config    playerConfig
+	functions *extractedFunctions
+	steps     []PlayerCheckStep
+}
+
+// CheckPlayer runs the extraction steps used for deciphering against the JavaScript of a player (base.js)
+// and compares the results with the cases. The steps depending on a failed step are left out,
+// so the last step of a chain is the one that broke.
+func CheckPlayer(js []byte, cases PlayerCases) []PlayerCheckStep {
+	check := &playerCheck{config: playerConfig(js), functions: &extractedFunctions{}}
+
+	sts, err := check.config.getSignatureTimestamp()
+	check.add("signature timestamp", sts, err)
+
+	check.signature(cases.Signature)
+	check.n(cases.N)
+
+	return check.steps
+}
+
+// add records a step and tells if it succeeded
+func (check *playerCheck) add(name, detail string, err error) bool {
+	check.steps = append(check.steps, PlayerCheckStep{Name: name, Detail: detail, Err: err})
+	return err == nil
+}
+
+func (check *playerCheck) signature(cases []PlayerCase) {
+	operations := check.operations()
+
+	// the fallback running the player's own function
+	fn, err := check.config.getSignatureFunction(check.functions)
+	if !check.add("signature function", fmt.Sprintf("%d bytes", len(fn)), err) && !operations {
+		return
+	}
+
+	check.add("signature cases", fmt.Sprintf("%d cases", len(cases)), checkCases(cases, func(input string) (string, error) {
+		output, err := check.config.decrypt(context.Background(), []byte(input), check.functions)
+		return string(output), err
+	}))
+}
+
+// operations checks the extraction of the operations, which are tried before the fallback
+func (check *playerCheck) operations() (ok bool) {
+	first := len(check.steps)
+	defer func() {
+		for i := first; i < len(check.steps); i++ {
+			check.steps[i].Optional = true
+		}
+	}()
+
+	objResult := actionsObjRegexp.FindSubmatch(check.config)
+	if len(objResult) < 3 {
+		return check.add("actions object", "", errors.New("actionsObjRegexp does not match"))
+	}
+	check.add("actions object", string(objResult[1]), nil)
+
+	funcResult := actionsFuncRegexp.FindSubmatch(check.config)
+	if len(funcResult) < 2 {
+		return check.add("actions function", "", errors.New("actionsFuncRegexp does not match"))
+	}
+	check.add("actions function", string(funcResult[1]), nil)
+
+	operations, err := check.config.parseDecipherOps()
+	if err == nil && len(operations) == 0 {
+		err = errors.New("no operations found in the actions function")
+	}
+	return check.add("operations", fmt.Sprintf("%d operations", len(operations)), err)
+}
+
+func (check *playerCheck) n(cases []PlayerCase) {
+	nameResult := nFunctionNameRegexp.FindSubmatch(check.config)
+	if len(nameResult) < 2 {
+		check.add("n function name", "", errors.New("nFunctionNameRegexp does not match"))
+		return
+	}
+	check.add("n function name", string(nameResult[1]), nil)
+
+	body, err := check.config.getNFunction(check.functions)
+	if !check.add("n function body", fmt.Sprintf("%d bytes", len(body)), err) {
+		return
+	}
+
+	check.add("n cases", fmt.Sprintf("%d cases", len(cases)), checkCases(cases, func(input string) (string, error) {
+		return evalJavascript(context.Background(), body, input)
+	}))
+}
+
+// checkCases returns an error for the first case with a different output
+func checkCases(cases []PlayerCase, fn func(input string) (string, error)) error {
+	for _, c := range cases {
+		output, err := fn(c.Input)
+		if err != nil {
+			return fmt.Errorf("%q: %w", c.Input, err)
+		}
+		if output != c.Output {
+			return fmt.Errorf("%q: got %q, want %q", c.Input, output, c.Output)
+		}
+	}
+	return nil
+}
@@ -1,32 +1,57 @@
-package youtube
-
-import "fmt"
-
-type constError string
-type ErrPlayabiltyStatus struct {
-	Status string
-	Reason string
-}
-
-const (
-	ErrCipherNotFound             = constError("cipher not found")
-	ErrInvalidCharactersInVideoID = constError("invalid characters in video id")
-	ErrSignatureTimestampNotFound = constError("signature timestamp not found")
-	ErrVideoPrivate               = constError("user restricted access to this video")
-	ErrVideoIDMinLength           = constError("the video id must be at least 10 characters long")
-)
-
-func (e constError) Error() string {
-	return string(e)
-}
-
-func (err ErrPlayabiltyStatus) Error() string {
-	return fmt.Sprintf("cannot playback and download, status: %s, reason: %s", err.Status, err.Reason)
-}
-
-// ErrUnexpectedHTTPStatusCode is returned on unexpected HTTP status codes
-type ErrUnexpectedHTTPStatusCode int
-
-func (err ErrUnexpectedHTTPStatusCode) Error() string {
-	return fmt.Sprintf("unexpected status code: %d", err)
-}
+package youtube
+
+import "fmt"
+
+type constError string
+type ErrPlayabiltyStatus struct {
+	Status string
+	Reason string
+}
+
+const (
+	ErrCipherNotFound             = constError("cipher not found")
+	ErrCommentsDisabled           = constError("comments are turned off")
+	ErrContentRangeMismatch       = constError("content range doesn't match the requested range")
+	ErrInvalidCharactersInVideoID = constError("invalid characters in video id")
+	ErrNoFormatMatches            = constError("no format matches the selector")
+	ErrNFunctionFailed            = constError("n-function of the player failed")
+	ErrNoMoreResults              = constError("no more results")
+	ErrNoProxies                  = constError("no proxies given")
+	ErrSignatureTimestampNotFound = constError("signature timestamp not found")
+	ErrUnsupportedURL             = constError("not a supported YouTube URL")
+	ErrUnsupportedProxyScheme     = constError("unsupported proxy scheme, use http, https, socks5 or socks5h")
+	ErrVideoPrivate               = constError("user restricted access to this video")
+	ErrVideoIDMinLength           = constError("the video id must be 11 characters long")
+)
+
+func (e constError) Error() string {
+	return string(e)
+}
+
+func (err ErrPlayabiltyStatus) Error() string {
+	return fmt.Sprintf("cannot playback and download, status: %s, reason: %s", err.Status, err.Reason)
+}
+
+// ErrUnexpectedHTTPStatusCode is returned on unexpected HTTP status codes
+type ErrUnexpectedHTTPStatusCode int
+
+func (err ErrUnexpectedHTTPStatusCode) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", err)
+}
+
+// ErrSizeMismatch is returned when a stream or a chunk of it is shorter or longer than announced.
+// The bytes up to Offset+Actual (at most Offset+Expected) were received, a download can be resumed from there.
+type ErrSizeMismatch struct {
+	Offset   int64
+	Expected int64
+	Actual   int64
+}
+
+func (err ErrSizeMismatch) Error() string {
+	return fmt.Sprintf("size mismatch at offset %d: expected %d bytes, got %d", err.Offset, err.Expected, err.Actual)
+}
+
+// Short reports whether less bytes than expected were received
+func (err ErrSizeMismatch) Short() bool {
+	return err.Actual < err.Expected
+}
@@ -1,29 +1,99 @@
-package youtube
-
-import (
-	"strconv"
-	"strings"
-)
-
-type FormatList []Format
-
-// Type returns a new FormatList filtered by mime type of video
-func (list FormatList) Type(t string) (result FormatList) {
-	for i := range list {
-		if strings.Contains(list[i].MimeType, t) {
-			result = append(result, list[i])
-		}
-	}
-	return result
-}
-
-// Quality returns a new FormatList filtered by quality, quality label or itag,
-func (list FormatList) Quality(quality string) (result FormatList) {
-	for _, f := range list {
-		itag, _ := strconv.Atoi(quality)
-		if itag == f.ItagNo || strings.Contains(f.Quality, quality) || strings.Contains(f.QualityLabel, quality) {
-			result = append(result, f)
-		}
-	}
-	return result
-}
+package youtube
+
+import (
+	"strconv"
+	"strings"
+)
+
+type FormatList []Format
+
+// Type returns a new FormatList filtered by mime type of video
+func (list FormatList) Type(t string) (result FormatList) {
+	for i := range list {
+		if strings.Contains(list[i].MimeType, t) {
+			result = append(result, list[i])
+		}
+	}
+	return result
+}
+
+// Quality returns a new FormatList filtered by quality, quality label or itag,
+func (list FormatList) Quality(quality string) (result FormatList) {
+	for _, f := range list {
+		itag, _ := strconv.Atoi(quality)
+		if itag == f.ItagNo || strings.Contains(f.Quality, quality) || strings.Contains(f.QualityLabel, quality) {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// Itag returns a new FormatList with the formats of the given itag
+func (list FormatList) Itag(itag int) (result FormatList) {
+	for _, f := range list {
+		if f.ItagNo == itag {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// AudioOnly returns a new FormatList with the formats without video
+func (list FormatList) AudioOnly() (result FormatList) {
+	for _, f := range list {
+		if f.HasAudio() && !f.HasVideo() {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// VideoOnly returns a new FormatList with the formats without audio
+func (list FormatList) VideoOnly() (result FormatList) {
+	for _, f := range list {
+		if f.HasVideo() && !f.HasAudio() {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// Best returns the format with the highest quality, nil if the list is empty
+func (list FormatList) Best() *Format {
+	var best *Format
+	for i := range list {
+		if best == nil || compareFormats(&list[i], best) > 0 {
+			best = &list[i]
+		}
+	}
+	return best
+}
+
+// Worst returns the format with the lowest quality, nil if the list is empty
+func (list FormatList) Worst() *Format {
+	var worst *Format
+	for i := range list {
+		if worst == nil || compareFormats(&list[i], worst) < 0 {
+			worst = &list[i]
+		}
+	}
+	return worst
+}
+
+// compareFormats orders formats by resolution, frame rate and bitrate
+func compareFormats(a, b *Format) int {
+	keys := [][2]int{
+		{a.Height, b.Height},
+		{a.FPS, b.FPS},
+		{a.Bitrate, b.Bitrate},
+	}
+	for _, k := range keys {
+		if k[0] != k[1] {
+			if k[0] > k[1] {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
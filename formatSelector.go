@@ -0,0 +1,174 @@
+package youtube
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// filterRegexp matches a single filter of a selector like [height<=720]
+var filterRegexp = regexp.MustCompile(`^\[(\w+)(<=|>=|!=|<|>|=)([^\]]+)\]`)
+
+// knownContainers can be used as the base of a selector
+var knownContainers = map[string]bool{"mp4": true, "webm": true, "3gpp": true}
+
+// Select picks a format by a selector.
+//
+// A selector is one or more alternatives separated by '/', the first one matching any format wins.
+// An alternative starts with best, worst, bestaudio, worstaudio, bestvideo, worstvideo,
+// an itag or a container like mp4, followed by filters in brackets, e.g.
+//
+//	bestvideo[height<=1080][ext=mp4]/best
+//
+// Filters support the keys itag, height, width, fps, bitrate, size, ext and codec
+// with the operators =, !=, <, <=, > and >= (only = and != for ext and codec).
+func (list FormatList) Select(selector string) (*Format, error) {
+	for _, alternative := range strings.Split(selector, "/") {
+		format, err := list.selectAlternative(strings.TrimSpace(alternative))
+		if err != nil {
+			return nil, err
+		}
+		if format != nil {
+			return format, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrNoFormatMatches, selector)
+}
+
+// selectAlternative returns nil if no format matches the alternative
+func (list FormatList) selectAlternative(alternative string) (*Format, error) {
+	base := alternative
+	if i := strings.IndexByte(alternative, '['); i >= 0 {
+		base = alternative[:i]
+	}
+
+	candidates, pickWorst, err := list.selectBase(base)
+	if err != nil {
+		return nil, err
+	}
+
+	for rest := alternative[len(base):]; rest != ""; {
+		match := filterRegexp.FindStringSubmatch(rest)
+		if match == nil {
+			return nil, fmt.Errorf("invalid format filter %q", rest)
+		}
+
+		var filtered FormatList
+		for _, f := range candidates {
+			ok, err := matchFilter(&f, match[1], match[2], match[3])
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				filtered = append(filtered, f)
+			}
+		}
+
+		candidates = filtered
+		rest = rest[len(match[0]):]
+	}
+
+	if pickWorst {
+		return candidates.Worst(), nil
+	}
+	return candidates.Best(), nil
+}
+
+// selectBase returns the formats the base of an alternative refers to
+func (list FormatList) selectBase(base string) (candidates FormatList, pickWorst bool, err error) {
+	switch base {
+	case "", "best", "worst":
+		// formats with audio and video
+		for _, f := range list {
+			if f.HasAudio() && f.HasVideo() {
+				candidates = append(candidates, f)
+			}
+		}
+		return candidates, base == "worst", nil
+	case "bestaudio", "worstaudio":
+		return list.AudioOnly(), base == "worstaudio", nil
+	case "bestvideo", "worstvideo":
+		return list.VideoOnly(), base == "worstvideo", nil
+	}
+
+	if itag, err := strconv.Atoi(base); err == nil {
+		return list.Itag(itag), false, nil
+	}
+
+	if !knownContainers[base] {
+		return nil, false, fmt.Errorf("invalid format selector %q", base)
+	}
+
+	for _, f := range list {
+		if f.Container() == base {
+			candidates = append(candidates, f)
+		}
+	}
+	return candidates, false, nil
+}
+
+// matchFilter checks a single filter against a format
+func matchFilter(f *Format, key, op, value string) (bool, error) {
+	switch key {
+	case "ext", "container":
+		return compareStrings(f.Container(), op, value)
+	case "codec":
+		match := false
+		for _, codec := range f.Codecs() {
+			if strings.HasPrefix(codec, value) {
+				match = true
+			}
+		}
+		return compareStrings(strconv.FormatBool(match), op, "true")
+	}
+
+	var actual int64
+	switch key {
+	case "itag":
+		actual = int64(f.ItagNo)
+	case "height":
+		actual = int64(f.Height)
+	case "width":
+		actual = int64(f.Width)
+	case "fps":
+		actual = int64(f.FPS)
+	case "bitrate":
+		actual = int64(f.Bitrate)
+	case "size":
+		actual = f.ContentLength
+	default:
+		return false, fmt.Errorf("invalid format filter key %q", key)
+	}
+
+	expected, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("invalid number in format filter: %q", value)
+	}
+
+	switch op {
+	case "=":
+		return actual == expected, nil
+	case "!=":
+		return actual != expected, nil
+	case "<":
+		return actual < expected, nil
+	case "<=":
+		return actual <= expected, nil
+	case ">":
+		return actual > expected, nil
+	default:
+		return actual >= expected, nil
+	}
+}
+
+func compareStrings(actual, op, expected string) (bool, error) {
+	switch op {
+	case "=":
+		return actual == expected, nil
+	case "!=":
+		return actual != expected, nil
+	}
+	return false, fmt.Errorf("operator %s is not supported for text", op)
+}
@@ -0,0 +1,137 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+const (
+	// javascriptTimeout limits a call of a player function, unless the context ends earlier.
+	// goja has no memory limit, the deadline also bounds what a runaway function allocates.
+	javascriptTimeout = 5 * time.Second
+	// maxJavascriptCallStack stops runaway recursion
+	maxJavascriptCallStack = 1024
+	// maxJavascriptOutput is the longest string a player function may return, its output becomes part of a URL
+	maxJavascriptOutput = 64 << 10
+	// maxJavascriptPrograms is the number of player functions kept compiled, the oldest are dropped
+	maxJavascriptPrograms = 8
+)
+
+// jsPrograms are the compiled function expressions by source, which differs for every player version
+type jsPrograms struct {
+	mu       sync.Mutex
+	programs map[string]*goja.Program
+	order    []string
+}
+
+var javascriptPrograms = &jsPrograms{programs: make(map[string]*goja.Program)}
+
+// evalJavascript calls the function expression with arg.
+// Every call runs the compiled expression in a new runtime, so no state is kept between calls.
+// Exceptions, panics, the deadline of ctx and too long outputs are returned as errors.
+func evalJavascript(ctx context.Context, jsFunction, arg string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, javascriptTimeout)
+	defer cancel()
+
+	program, err := javascriptPrograms.get(jsFunction)
+	if err != nil {
+		return "", err
+	}
+
+	vm := goja.New()
+	vm.SetMaxCallStackSize(maxJavascriptCallStack)
+
+	// the expression may run code of the player before it returns the function
+	var value goja.Value
+	err = interruptible(ctx, vm, func() (err error) {
+		value, err = vm.RunProgram(program)
+		if err != nil {
+			return err
+		}
+
+		fn, ok := goja.AssertFunction(value)
+		if !ok {
+			return fmt.Errorf("%s is not a function", value.ExportType())
+		}
+		value, err = fn(goja.Undefined(), vm.ToValue(arg))
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	output, ok := value.Export().(string)
+	if !ok {
+		return "", fmt.Errorf("javascript: returned %v instead of a string", value)
+	}
+	if len(output) > maxJavascriptOutput {
+		return "", fmt.Errorf("javascript: returned %d bytes, at most %d are allowed", len(output), maxJavascriptOutput)
+	}
+	return output, nil
+}
+
+// get returns the compiled function expression, dropping the oldest program if there are too many
+func (p *jsPrograms) get(source string) (*goja.Program, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if program, ok := p.programs[source]; ok {
+		return program, nil
+	}
+
+	program, err := goja.Compile("", "("+source+")", false)
+	if err != nil {
+		return nil, fmt.Errorf("javascript: %w", err)
+	}
+
+	p.programs[source] = program
+	p.order = append(p.order, source)
+	if len(p.order) > maxJavascriptPrograms {
+		delete(p.programs, p.order[0])
+		p.order = p.order[1:]
+	}
+	return program, nil
+}
+
+// interruptible runs fn and interrupts the runtime when ctx ends.
+// JavaScript exceptions and panics are turned into errors.
+func interruptible(ctx context.Context, vm *goja.Runtime, fn func() error) (err error) {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-ctx.Done():
+			vm.Interrupt(ctx.Err())
+		case <-done:
+		}
+	}()
+	defer func() {
+		close(done)
+		<-exited
+		// an interrupt arriving after fn returned must not hit the next call
+		vm.ClearInterrupt()
+	}()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("javascript panicked: %v", r)
+		}
+	}()
+
+	err = fn()
+
+	var interrupted *goja.InterruptedError
+	if errors.As(err, &interrupted) {
+		return fmt.Errorf("javascript interrupted: %w", ctx.Err())
+	}
+	if err != nil {
+		return fmt.Errorf("javascript: %w", err)
+	}
+	return nil
+}
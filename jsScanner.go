@@ -0,0 +1,507 @@
+package youtube
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+type jsTokenKind int
+
+const (
+	jsEOF jsTokenKind = iota
+	jsIdent
+	jsNumber
+	jsString
+	jsRegexp
+	jsPunct
+)
+
+// jsToken is a token of JavaScript source, comments are skipped
+type jsToken struct {
+	kind       jsTokenKind
+	start, end int
+	text       string
+}
+
+// jsScanner splits JavaScript into tokens. It knows just enough of the syntax to step over
+// strings, template literals, regular expressions and comments, which may contain brackets.
+type jsScanner struct {
+	src  []byte
+	pos  int
+	last jsToken
+}
+
+// jsPunctuators are the operators longer than one character, longest first
+var jsPunctuators = []string{
+	">>>=", "...", "===", "!==", "**=", "<<=", ">>=", ">>>", "&&=", "||=", "??=",
+	"=>", "==", "!=", "<=", ">=", "&&", "||", "??", "?.", "++", "--",
+	"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "<<", ">>", "**",
+}
+
+// jsRegexpKeywords are the keywords after which a slash starts a regular expression
+var jsRegexpKeywords = map[string]bool{
+	"return": true, "typeof": true, "instanceof": true, "in": true, "of": true, "new": true,
+	"delete": true, "void": true, "throw": true, "case": true, "do": true, "else": true,
+}
+
+func newJSScanner(src []byte, pos int) *jsScanner {
+	return &jsScanner{src: src, pos: pos}
+}
+
+// next returns the next token, jsEOF at the end of the source
+func (s *jsScanner) next() (jsToken, error) {
+	if err := s.skipSpace(); err != nil {
+		return jsToken{}, err
+	}
+
+	start := s.pos
+	if start >= len(s.src) {
+		return jsToken{kind: jsEOF, start: start, end: start}, nil
+	}
+
+	var kind jsTokenKind
+	var err error
+	switch c := s.src[start]; {
+	case isJSIdentByte(c) && !isDigit(c):
+		kind = jsIdent
+		for s.pos < len(s.src) && isJSIdentByte(s.src[s.pos]) {
+			s.pos++
+		}
+	case isDigit(c) || c == '.' && start+1 < len(s.src) && isDigit(s.src[start+1]):
+		kind = jsNumber
+		s.scanNumber(start)
+	case c == '"' || c == '\'':
+		kind = jsString
+		err = s.scanString(c)
+	case c == '`':
+		kind = jsString
+		err = s.scanTemplate()
+	case c == '/' && s.regexpAllowed():
+		kind = jsRegexp
+		err = s.scanRegexp()
+	default:
+		kind = jsPunct
+		s.pos++
+		for _, p := range jsPunctuators {
+			if bytes.HasPrefix(s.src[start:], []byte(p)) {
+				s.pos = start + len(p)
+				break
+			}
+		}
+	}
+	if err != nil {
+		return jsToken{}, fmt.Errorf("%w at offset %d", err, start)
+	}
+
+	s.last = jsToken{kind: kind, start: start, end: s.pos, text: string(s.src[start:s.pos])}
+	return s.last, nil
+}
+
+// peek returns the next token without consuming it
+func (s *jsScanner) peek() (jsToken, error) {
+	saved := *s
+	token, err := s.next()
+	*s = saved
+	return token, err
+}
+
+func (s *jsScanner) skipSpace() error {
+	for s.pos < len(s.src) {
+		switch c := s.src[s.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			s.pos++
+		case c == '/' && s.pos+1 < len(s.src) && s.src[s.pos+1] == '/':
+			for s.pos < len(s.src) && s.src[s.pos] != '\n' {
+				s.pos++
+			}
+		case c == '/' && s.pos+1 < len(s.src) && s.src[s.pos+1] == '*':
+			end := bytes.Index(s.src[s.pos+2:], []byte("*/"))
+			if end < 0 {
+				return errors.New("unterminated comment")
+			}
+			s.pos += 2 + end + 2
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *jsScanner) scanNumber(start int) {
+	hex := s.src[start] == '0' && start+1 < len(s.src) && s.src[start+1]|0x20 == 'x'
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case isJSIdentByte(c) || c == '.':
+			s.pos++
+		case (c == '+' || c == '-') && !hex && (s.src[s.pos-1] == 'e' || s.src[s.pos-1] == 'E'):
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (s *jsScanner) scanString(quote byte) error {
+	for s.pos++; s.pos < len(s.src); s.pos++ {
+		switch s.src[s.pos] {
+		case '\\':
+			s.pos++
+		case '\n':
+			return errors.New("unterminated string")
+		case quote:
+			s.pos++
+			return nil
+		}
+	}
+	return errors.New("unterminated string")
+}
+
+// scanTemplate steps over a template literal, the expressions in ${} are scanned as code
+func (s *jsScanner) scanTemplate() error {
+	for s.pos++; s.pos < len(s.src); s.pos++ {
+		switch s.src[s.pos] {
+		case '\\':
+			s.pos++
+		case '`':
+			s.pos++
+			return nil
+		case '$':
+			if s.pos+1 < len(s.src) && s.src[s.pos+1] == '{' {
+				end, err := closingBracket(s.src, s.pos+1)
+				if err != nil {
+					return err
+				}
+				s.pos = end - 1
+			}
+		}
+	}
+	return errors.New("unterminated template literal")
+}
+
+func (s *jsScanner) scanRegexp() error {
+	inClass := false
+	for s.pos++; s.pos < len(s.src); s.pos++ {
+		switch s.src[s.pos] {
+		case '\\':
+			s.pos++
+		case '\n':
+			return errors.New("unterminated regular expression")
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '/':
+			if inClass {
+				continue
+			}
+			// flags
+			for s.pos++; s.pos < len(s.src) && isJSIdentByte(s.src[s.pos]); s.pos++ {
+			}
+			return nil
+		}
+	}
+	return errors.New("unterminated regular expression")
+}
+
+// regexpAllowed tells a regular expression from a division by the token in front of the slash
+func (s *jsScanner) regexpAllowed() bool {
+	switch s.last.kind {
+	case jsEOF:
+		return true
+	case jsIdent:
+		return jsRegexpKeywords[s.last.text]
+	case jsPunct:
+		switch s.last.text {
+		case ")", "]", "}", "++", "--":
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+func isJSIdentByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c) || c == '_' || c == '$' || c >= 0x80
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// closingBracket returns the position behind the bracket closing the one at open
+func closingBracket(js []byte, open int) (int, error) {
+	if open < 0 || open >= len(js) {
+		return 0, errors.New("no opening bracket")
+	}
+
+	s := newJSScanner(js, open)
+	depth := 0
+	for {
+		token, err := s.next()
+		if err != nil {
+			return 0, err
+		}
+
+		switch token.text {
+		case "{", "(", "[":
+			depth++
+		case "}", ")", "]":
+			depth--
+			if depth == 0 {
+				return token.end, nil
+			}
+		}
+		if token.kind == jsEOF {
+			return 0, errors.New("unbalanced brackets")
+		}
+	}
+}
+
+// expressionEnd returns the end of the expression starting at start,
+// which ends with a comma, a semicolon or a closing bracket outside of brackets
+func expressionEnd(js []byte, start int) (int, error) {
+	s := newJSScanner(js, start)
+	s.last = jsToken{kind: jsPunct, text: "="}
+
+	depth := 0
+	for {
+		token, err := s.next()
+		if err != nil {
+			return 0, err
+		}
+		if token.kind == jsEOF {
+			return token.start, nil
+		}
+
+		switch token.text {
+		case "{", "(", "[":
+			depth++
+		case "}", ")", "]":
+			if depth == 0 {
+				return token.start, nil
+			}
+			depth--
+		case ",", ";":
+			if depth == 0 {
+				return token.start, nil
+			}
+		}
+	}
+}
+
+// jsBuiltins are names of the language and its runtime, they are never looked up in the player
+var jsBuiltins = map[string]bool{
+	"arguments": true, "Array": true, "Boolean": true, "break": true, "case": true, "catch": true, "const": true,
+	"continue": true, "Date": true, "decodeURIComponent": true, "default": true, "delete": true, "do": true,
+	"else": true, "encodeURIComponent": true, "Error": true, "false": true, "finally": true, "for": true,
+	"function": true, "if": true, "in": true, "Infinity": true, "instanceof": true, "isNaN": true, "JSON": true,
+	"let": true, "Math": true, "NaN": true, "new": true, "null": true, "Number": true, "Object": true, "of": true,
+	"parseFloat": true, "parseInt": true, "RegExp": true, "return": true, "String": true, "switch": true,
+	"Symbol": true, "this": true, "throw": true, "true": true, "try": true, "typeof": true, "undefined": true,
+	"var": true, "void": true, "while": true,
+}
+
+// freeIdentifiers returns the names the code uses without declaring them, in order of appearance.
+// Declarations are collected for the whole code, scopes are not told apart.
+func freeIdentifiers(js []byte) ([]string, error) {
+	s := newJSScanner(js, 0)
+
+	var (
+		brackets    []string
+		declared    = make(map[string]bool)
+		used        []string
+		prev        jsToken
+		declDepth   = -1 // brackets around the current var statement, -1 outside of one
+		expectDecl  bool // the next name is declared
+		inFunction  bool // between the function keyword and its parameters
+		paramsDepth = -1 // brackets around the current parameter list, -1 outside of one
+	)
+	for {
+		token, err := s.next()
+		if err != nil {
+			return nil, err
+		}
+		if token.kind == jsEOF {
+			break
+		}
+
+		switch token.kind {
+		case jsIdent:
+			next, err := s.peek()
+			if err != nil {
+				return nil, err
+			}
+
+			switch {
+			case token.text == "var" || token.text == "let" || token.text == "const":
+				declDepth, expectDecl = len(brackets), true
+			case token.text == "function":
+				inFunction = true
+			case prev.text == "." || prev.text == "?.":
+				// a property
+			case expectDecl || inFunction || paramsDepth == len(brackets) || next.text == "=>":
+				declared[token.text] = true
+				expectDecl = false
+			case next.text == ":" && (prev.text == "{" || prev.text == ",") && len(brackets) > 0 && brackets[len(brackets)-1] == "{":
+				// a key of an object literal
+			case !jsBuiltins[token.text]:
+				used = append(used, token.text)
+			}
+
+		case jsPunct:
+			switch token.text {
+			case "(", "[", "{":
+				brackets = append(brackets, token.text)
+				if token.text == "(" && inFunction {
+					inFunction, paramsDepth = false, len(brackets)
+				}
+			case ")", "]", "}":
+				if len(brackets) > 0 {
+					brackets = brackets[:len(brackets)-1]
+				}
+				if paramsDepth > len(brackets) {
+					paramsDepth = -1
+				}
+				if declDepth > len(brackets) {
+					declDepth = -1
+				}
+			case ",":
+				if declDepth == len(brackets) {
+					expectDecl = true
+				}
+			case ";":
+				if declDepth == len(brackets) {
+					declDepth = -1
+				}
+			}
+		}
+		prev = token
+	}
+
+	var free []string
+	seen := make(map[string]bool)
+	for _, name := range used {
+		if !declared[name] && !seen[name] {
+			seen[name] = true
+			free = append(free, name)
+		}
+	}
+	return free, nil
+}
+
+// jsDefinition is a name the player assigns outside of expressions, either a value or a function declaration
+type jsDefinition struct {
+	start       int
+	depth       int
+	declaration bool
+}
+
+// jsDefinitions collects the definitions of the player by name and returns the depth of curly brackets at position at
+func jsDefinitions(js []byte, at int) (map[string][]jsDefinition, int, error) {
+	s := newJSScanner(js, 0)
+	defs := make(map[string][]jsDefinition)
+	atDepth := -1
+
+	var brackets []string
+	curly := 0
+	var prev jsToken
+	for {
+		token, err := s.next()
+		if err != nil {
+			return nil, 0, err
+		}
+		if token.kind == jsEOF {
+			break
+		}
+		if atDepth < 0 && token.start >= at {
+			atDepth = curly
+		}
+
+		switch token.text {
+		case "(", "[", "{":
+			brackets = append(brackets, token.text)
+			if token.text == "{" {
+				curly++
+			}
+		case ")", "]", "}":
+			if len(brackets) > 0 {
+				if brackets[len(brackets)-1] == "{" {
+					curly--
+				}
+				brackets = brackets[:len(brackets)-1]
+			}
+		}
+
+		statement := len(brackets) == 0 || brackets[len(brackets)-1] == "{"
+		if token.kind == jsIdent && statement {
+			next, err := s.peek()
+			if err != nil {
+				return nil, 0, err
+			}
+
+			switch {
+			case token.text == "function" && next.kind == jsIdent && isStatementStart(prev):
+				defs[next.text] = append(defs[next.text], jsDefinition{start: token.start, depth: curly, declaration: true})
+			case next.text == "=" && !jsBuiltins[token.text] && (isStatementStart(prev) || prev.text == "," ||
+				prev.text == "var" || prev.text == "let" || prev.text == "const"):
+				defs[token.text] = append(defs[token.text], jsDefinition{start: next.end, depth: curly})
+			}
+		}
+		prev = token
+	}
+
+	if atDepth < 0 {
+		atDepth = curly
+	}
+	return defs, atDepth, nil
+}
+
+// isStatementStart tells if a statement can start behind the token
+func isStatementStart(prev jsToken) bool {
+	switch prev.text {
+	case "", ";", "{", "}":
+		return true
+	}
+	return false
+}
+
+// source returns the assigned value or the declared function
+func (def jsDefinition) source(js []byte) ([]byte, error) {
+	if !def.declaration {
+		end, err := expressionEnd(js, def.start)
+		if err != nil {
+			return nil, err
+		}
+		return js[def.start:end], nil
+	}
+
+	end, err := functionEnd(js, def.start)
+	if err != nil {
+		return nil, err
+	}
+	return js[def.start:end], nil
+}
+
+// functionEnd returns the end of the function starting with the function keyword at start
+func functionEnd(js []byte, start int) (int, error) {
+	params := bytes.IndexByte(js[start:], '(')
+	if params < 0 {
+		return 0, errors.New("no parameters")
+	}
+	end, err := closingBracket(js, start+params)
+	if err != nil {
+		return 0, err
+	}
+
+	s := newJSScanner(js, end)
+	token, err := s.next()
+	if err != nil {
+		return 0, err
+	}
+	if token.text != "{" {
+		return 0, fmt.Errorf("no function body at offset %d", token.start)
+	}
+	return closingBracket(js, token.start)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/yigitcilce/youtube"
+)
+
+// defaultJobs is the number of concurrent downloads in batch mode
+const defaultJobs = 3
+
+// batchResult is the outcome of one download of a batch
+type batchResult struct {
+	input string
+	err   error
+}
+
+// readBatch reads one URL or ID per line, empty lines and lines starting with # or ; are skipped
+func readBatch(r io.Reader) ([]string, error) {
+	var inputs []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		inputs = append(inputs, line)
+	}
+	return inputs, scanner.Err()
+}
+
+// readBatchFile reads a batch file, "-" is stdin
+func readBatchFile(path string) ([]string, error) {
+	if path == "-" {
+		return readBatch(os.Stdin)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return readBatch(f)
+}
+
+// stdinIsPiped tells whether URLs might be waiting on stdin instead of a terminal
+func stdinIsPiped() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
+// dedupeVideos drops inputs pointing to a video that is already in the list,
+// URLs and IDs of the same video count as duplicates. Invalid inputs are kept
+// so that they show up as failures.
+func dedupeVideos(inputs []string) []string {
+	seen := make(map[string]bool, len(inputs))
+	unique := inputs[:0:0]
+	for _, input := range inputs {
+		key := input
+		if id, err := youtube.ExtractVideoID(input); err == nil {
+			key = id
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, input)
+	}
+	return unique
+}
+
+// downloadBatch runs fn for every input with at most jobs at the same time,
+// the results are in the order of the inputs
+func downloadBatch(ctx context.Context, inputs []string, jobs int, fn func(ctx context.Context, input string) error) []batchResult {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	results := make([]batchResult, len(inputs))
+	queue := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < jobs && i < len(inputs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				err := ctx.Err()
+				if err == nil {
+					err = fn(ctx, inputs[i])
+				}
+				results[i] = batchResult{input: inputs[i], err: err}
+			}
+		}()
+	}
+
+	for i := range inputs {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	return results
+}
+
+// printSummary lists the failed downloads and returns an error if there are any
+func printSummary(w io.Writer, results []batchResult) error {
+	var failed int
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+			fmt.Fprintf(w, "FAILED %s: %v\n", result.input, result.err)
+		}
+	}
+
+	fmt.Fprintf(w, "%d downloaded, %d failed\n", len(results)-failed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d downloads failed", failed, len(results))
+	}
+	return nil
+}
@@ -1,36 +1,177 @@
-package main
-
-import (
-	"context"
-
-	// For command line operations
-	"github.com/spf13/cobra"
-)
-
-// downloadCmd represents the download command
-var downloadCmd = &cobra.Command{
-	Use:     "mp4",
-	Short:   "Downloads a video from youtube",
-	Example: `./main download Jl8fV1jUQPs -> for downloading https://www.youtube.com/watch?v=Jl8fV1jUQPs`,
-	Args:    cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		exitOnError(download(args[0]))
-	},
-}
-
-// Initializes rootCommand and waits for
-func init() {
-	rootCmd.AddCommand(downloadCmd)
-}
-
-// download is the highest level functionality for downloading, currently only works for mp4
-func download(id string) error {
-	// Flag 1: Get video information from Youtube API
-	video, format, err := getVideoWithFormat(id)
-	if err != nil {
-		return err
-	}
-
-	// Flag 2: Create an mp4 file with content from API
-	return downloader.Download(context.Background(), video, format)
-}
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	// For command line operations
+	"github.com/spf13/cobra"
+	"github.com/vbauerster/mpb/v5"
+
+	"github.com/yigitcilce/youtube"
+)
+
+// downloadCmd represents the download command
+var downloadCmd = &cobra.Command{
+	Use:   "mp4 [URL or ID]...",
+	Short: "Downloads videos from youtube",
+	Example: `./main mp4 Jl8fV1jUQPs -> for downloading https://www.youtube.com/watch?v=Jl8fV1jUQPs
+./main mp4 --batch-file list.txt -> for downloading every URL or ID in list.txt, one per line
+cat list.txt | ./main mp4 -> the same from stdin`,
+	Run: func(cmd *cobra.Command, args []string) {
+		getDownloader().SHA256, _ = cmd.Flags().GetBool("sha256")
+		getDownloader().OutputTemplate, _ = cmd.Flags().GetString("output")
+		getDownloader().ExtractAudio = extractAudio
+		getDownloader().WriteThumbnail, _ = cmd.Flags().GetBool("write-thumbnail")
+		getDownloader().EmbedThumbnail, _ = cmd.Flags().GetBool("embed-thumbnail")
+		getDownloader().WriteInfoJSON, _ = cmd.Flags().GetBool("write-info-json")
+		getDownloader().WriteNFO, _ = cmd.Flags().GetBool("write-nfo")
+		exitOnError(checkAudioFormat(extractAudio, audioFormat))
+
+		if archivePath != "" {
+			archive, err := youtube.OpenFileArchive(archivePath)
+			exitOnError(err)
+			defer archive.Close()
+			getDownloader().Archive = archive
+		}
+
+		inputs, err := collectInputs(args, batchFile)
+		exitOnError(err)
+		exitOnError(downloadAll(inputs, jobs))
+	},
+}
+
+// Initializes rootCommand and waits for
+func init() {
+	rootCmd.AddCommand(downloadCmd)
+
+	downloadCmd.Flags().Bool("sha256", false, "compute and print the SHA-256 checksum of the download")
+	downloadCmd.Flags().StringVar(&batchFile, "batch-file", "", "file with one URL or ID per line, - for stdin")
+	downloadCmd.Flags().IntVarP(&jobs, "jobs", "j", defaultJobs, "number of concurrent downloads in batch mode")
+	downloadCmd.Flags().StringVar(&archivePath, "download-archive", "", "file recording downloaded videos, those are skipped next time")
+	downloadCmd.Flags().BoolVar(&force, "force", false, "download videos even if they are in the download archive")
+	downloadCmd.Flags().Bool("write-thumbnail", false, "save the thumbnail next to the video as .jpg")
+	downloadCmd.Flags().Bool("embed-thumbnail", false, "add the thumbnail as cover to MP4, M4A and Opus files")
+	downloadCmd.Flags().Bool("write-info-json", false, "save the video metadata and the format next to the video as .info.json")
+	downloadCmd.Flags().Bool("write-nfo", false, "save the video metadata as Kodi/Jellyfin .nfo next to the video")
+	downloadCmd.Flags().StringP("output", "o", defaultOutputTemplate, `output path template, e.g. '{{.Channel}}/{{.PublishDate.Format "2006-01-02"}} - {{.Title}} [{{.ID}}].{{.Ext}}'`)
+
+	// Format selection, without any of them the first format is downloaded
+	downloadCmd.Flags().IntVar(&formatOpts.itag, "itag", 0, "download the format with this itag")
+	downloadCmd.Flags().StringVar(&formatOpts.quality, "quality", "", "quality or quality label like hd720 or 1080p")
+	downloadCmd.Flags().StringVar(&formatOpts.mime, "mime", "", "part of the mime type like video/webm or avc1")
+	downloadCmd.Flags().BoolVar(&formatOpts.audioOnly, "audio-only", false, "only formats without video")
+	downloadCmd.Flags().BoolVar(&formatOpts.videoOnly, "video-only", false, "only formats without audio")
+	downloadCmd.Flags().StringVar(&formatOpts.selector, "format", "", "format selector like bestvideo[height<=720]/best, replaces the other format flags")
+
+	// Audio extraction picks the best audio format unless a format is chosen
+	downloadCmd.Flags().BoolVar(&extractAudio, "extract-audio", false, "write the best audio format into an .m4a or .opus file with tags")
+	downloadCmd.Flags().StringVar(&audioFormat, "audio-format", "", "preferred audio file with --extract-audio: m4a or opus")
+}
+
+// formatOpts holds the format flags of the download command
+var formatOpts formatOptions
+
+// batchFile and jobs hold the batch flags of the download command
+var (
+	batchFile string
+	jobs      int
+)
+
+// archivePath and force hold the archive flags of the download command
+var (
+	archivePath string
+	force       bool
+)
+
+// extractAudio and audioFormat hold the audio flags of the download command
+var (
+	extractAudio bool
+	audioFormat  string
+)
+
+// audioFormatSelectors are the format selectors of the --audio-format values
+var audioFormatSelectors = map[string]string{
+	"":     "bestaudio",
+	"m4a":  "bestaudio[ext=mp4]",
+	"opus": "bestaudio[ext=webm][codec=opus]",
+}
+
+// checkAudioFormat rejects unknown audio formats and an audio format without audio extraction
+func checkAudioFormat(extractAudio bool, audioFormat string) error {
+	if _, ok := audioFormatSelectors[audioFormat]; !ok {
+		return fmt.Errorf("unknown audio format %q, use m4a or opus", audioFormat)
+	}
+	if audioFormat != "" && !extractAudio {
+		return fmt.Errorf("--audio-format %s needs --extract-audio", audioFormat)
+	}
+	return nil
+}
+
+// collectInputs gathers the videos from the arguments, the batch file and stdin, without duplicates
+func collectInputs(args []string, batchFile string) ([]string, error) {
+	inputs := args
+	if batchFile != "" {
+		lines, err := readBatchFile(batchFile)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, lines...)
+	} else if len(args) == 0 && stdinIsPiped() {
+		lines, err := readBatch(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		inputs = lines
+	}
+
+	if len(inputs) == 0 {
+		return nil, errors.New("no videos given, pass URLs or IDs as arguments, with --batch-file or on stdin")
+	}
+	return dedupeVideos(inputs), nil
+}
+
+// downloadAll downloads a single video directly and more of them with a pool of workers sharing the client
+func downloadAll(inputs []string, jobs int) error {
+	if len(inputs) == 1 {
+		return download(context.Background(), inputs[0], formatOpts)
+	}
+
+	progress := mpb.New(mpb.WithWidth(80))
+	getDownloader().Progress = progress
+
+	results := downloadBatch(context.Background(), inputs, jobs, func(ctx context.Context, input string) error {
+		return download(ctx, input, formatOpts)
+	})
+	progress.Wait()
+
+	return printSummary(os.Stderr, results)
+}
+
+// download is the highest level functionality for downloading, currently only works for mp4
+func download(ctx context.Context, id string, opts formatOptions) error {
+	if err := checkAudioFormat(extractAudio, audioFormat); err != nil {
+		return err
+	}
+	if extractAudio && opts == (formatOptions{}) {
+		opts.selector = audioFormatSelectors[audioFormat]
+	}
+
+	// Flag 1: Get video information from Youtube API
+	video, format, err := getVideoWithFormat(ctx, id, opts)
+	if err != nil {
+		return err
+	}
+
+	// The archive has a file per format, which is known once the video is fetched
+	if !force {
+		if skip, err := downloader.archived(video.ID, format.ItagNo); err != nil || skip {
+			return err
+		}
+	}
+
+	// Flag 2: Create an mp4 file with content from API
+	return downloader.Download(ctx, video, format)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yigitcilce/youtube"
+)
+
+// commentsCmd exports the comments of a video
+var commentsCmd = &cobra.Command{
+	Use:   "comments",
+	Short: "Exports the comments of a video as JSON lines or CSV",
+	Example: `./main comments https://www.youtube.com/watch?v=rFejpH_tAHM --sort newest --replies -o comments.jsonl
+./main comments rFejpH_tAHM --format csv --limit 1000 > comments.csv`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		format, _ := flags.GetString("format")
+		sort, _ := flags.GetString("sort")
+		replies, _ := flags.GetBool("replies")
+		limit, _ := flags.GetInt("limit")
+		output, _ := flags.GetString("output")
+
+		var out io.Writer = os.Stdout
+		if output != "" && output != "-" {
+			f, err := os.Create(output)
+			exitOnError(err)
+			defer f.Close()
+			out = f
+		}
+
+		it := getDownloader().GetComments(context.Background(), args[0], &youtube.CommentOptions{
+			Sort:    youtube.CommentSort(sort),
+			Replies: replies,
+		})
+		n, err := exportComments(out, it, format, limit)
+		exitOnError(err)
+		fmt.Fprintf(os.Stderr, "%d comments exported\n", n)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(commentsCmd)
+
+	commentsCmd.Flags().String("format", "jsonl", "jsonl or csv")
+	commentsCmd.Flags().String("sort", "top", "top or newest comments first")
+	commentsCmd.Flags().Bool("replies", false, "also export the replies, each follows its comment")
+	commentsCmd.Flags().Int("limit", 0, "maximum number of comments and replies, 0 exports all")
+	commentsCmd.Flags().StringP("output", "o", "-", "output file, - is stdout")
+}
+
+// commentColumns are the columns of the CSV export
+var commentColumns = []string{"id", "parent_id", "author", "author_channel_id", "text", "likes", "reply_count", "pinned", "hearted", "edited", "published", "published_at"}
+
+// exportComments writes the comments of the iterator as they come in and returns how many were written
+func exportComments(out io.Writer, it *youtube.CommentIterator, format string, limit int) (int, error) {
+	var write func(youtube.Comment) error
+	var flush func() error
+
+	switch format {
+	case "jsonl":
+		encoder := json.NewEncoder(out)
+		write = func(comment youtube.Comment) error {
+			return encoder.Encode(comment)
+		}
+		flush = func() error { return nil }
+
+	case "csv":
+		writer := csv.NewWriter(out)
+		if err := writer.Write(commentColumns); err != nil {
+			return 0, err
+		}
+		write = func(comment youtube.Comment) error {
+			return writer.Write([]string{
+				comment.ID,
+				comment.ParentID,
+				comment.Author,
+				comment.AuthorChannelID,
+				comment.Text,
+				strconv.Itoa(comment.Likes),
+				strconv.Itoa(comment.ReplyCount),
+				strconv.FormatBool(comment.Pinned),
+				strconv.FormatBool(comment.Hearted),
+				strconv.FormatBool(comment.Edited),
+				comment.Published,
+				comment.PublishedAt.Format(time.RFC3339),
+			})
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+
+	default:
+		return 0, fmt.Errorf("unknown format %q, use jsonl or csv", format)
+	}
+
+	var n int
+	for (limit <= 0 || n < limit) && it.Next() {
+		if err := write(it.Comment()); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := flush(); err != nil {
+		return n, err
+	}
+	return n, it.Err()
+}
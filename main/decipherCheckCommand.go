@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yigitcilce/youtube"
+)
+
+// decipherCheckCmd tells which extraction step breaks on a new player
+var decipherCheckCmd = &cobra.Command{
+	Use:   "decipher-check",
+	Short: "Checks the signature and n-parameter extraction against a player base.js",
+	Long: `Runs the extraction steps against a player base.js and compares the deciphered
+signatures and n-parameters with the known results in a cases.json, by default the
+one next to base.js (see testdata/players).`,
+	Example: `./main decipher-check testdata/players/classic-operations/base.js`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		casesFile, _ := cmd.Flags().GetString("cases")
+		if casesFile == "" {
+			casesFile = filepath.Join(filepath.Dir(args[0]), "cases.json")
+		}
+		exitOnError(decipherCheck(os.Stdout, args[0], casesFile))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(decipherCheckCmd)
+
+	decipherCheckCmd.Flags().String("cases", "", "file with the known results, defaults to cases.json next to base.js")
+}
+
+// decipherCheck prints every step and fails if one of them failed
+func decipherCheck(w io.Writer, playerFile, casesFile string) error {
+	js, err := os.ReadFile(playerFile)
+	if err != nil {
+		return err
+	}
+
+	var cases youtube.PlayerCases
+	data, err := os.ReadFile(casesFile)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, &cases); err != nil {
+		return fmt.Errorf("invalid cases file %s: %w", casesFile, err)
+	}
+
+	failed := 0
+	for _, step := range youtube.CheckPlayer(js, cases) {
+		switch {
+		case step.Err != nil && step.Optional:
+			fmt.Fprintf(w, "WARN  %-20s %v (JavaScript fallback is used)\n", step.Name, step.Err)
+			continue
+		case step.Err != nil:
+			failed++
+			fmt.Fprintf(w, "FAIL  %-20s %v\n", step.Name, step.Err)
+			continue
+		}
+		fmt.Fprintf(w, "OK    %-20s %s\n", step.Name, step.Detail)
+	}
+
+	if failed > 0 {
+		return errors.New("decipher check failed")
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"unicode/utf8"
+
+	"github.com/yigitcilce/youtube"
+)
+
+// defaultOutputTemplate names the file after the video title in the current directory
+const defaultOutputTemplate = "{{.Title}}.{{.Ext}}"
+
+// maxFileNameLength is the limit of a single path element on most file systems, in bytes
+const maxFileNameLength = 255
+
+// fileNameData is what output templates can refer to, e.g. {{.Channel}}/{{.Title}} [{{.ID}}].{{.Ext}}
+type fileNameData struct {
+	youtube.Video
+	Format  youtube.Format
+	Channel string
+	Ext     string
+}
+
+// renderFileName executes the output template, placeholders can't add directories.
+// The path must stay in the directory the template starts with, e.g. out in out/{{.Title}}.{{.Ext}}.
+func renderFileName(outputTemplate string, v *youtube.Video, format *youtube.Format, ext string) (string, error) {
+	tmpl, err := template.New("output").
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"sanitizeFileName": sanitizeFileName}).
+		Parse(outputTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid output template: %w", err)
+	}
+	sanitizeActions(tmpl.Tree.Root)
+
+	data := fileNameData{
+		Video:   *v,
+		Format:  *format,
+		Channel: v.Author,
+		Ext:     ext,
+	}
+
+	var b strings.Builder
+	if err = tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("invalid output template: %w", err)
+	}
+	path := filepath.Clean(b.String())
+
+	// a title like .. must not climb out of the output directory
+	dir := outputTemplate
+	if i := strings.Index(dir, "{{"); i >= 0 {
+		dir = dir[:i]
+	}
+	dir = filepath.Dir(dir + "x")
+	if rel, err := filepath.Rel(dir, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("output path %q is outside of %q", path, dir)
+	}
+
+	return path, nil
+}
+
+// sanitizeActions pipes the output of every action of the template through sanitizeFileName,
+// the text of the template is kept as it is
+func sanitizeActions(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			sanitizeActions(child)
+		}
+	case *parse.ActionNode:
+		// declarations like {{$title := .Title}} print nothing
+		if len(n.Pipe.Decl) == 0 {
+			sanitize := parse.NewIdentifier("sanitizeFileName").SetTree(nil).SetPos(n.Pos)
+			n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{NodeType: parse.NodeCommand, Pos: n.Pos, Args: []parse.Node{sanitize}})
+		}
+	case *parse.IfNode:
+		sanitizeActions(n.List)
+		sanitizeActions(n.ElseList)
+	case *parse.RangeNode:
+		sanitizeActions(n.List)
+		sanitizeActions(n.ElseList)
+	case *parse.WithNode:
+		sanitizeActions(n.List)
+		sanitizeActions(n.ElseList)
+	}
+}
+
+// sanitizeFileName turns the value of a placeholder into a part of a path element
+func sanitizeFileName(value interface{}) string {
+	return strings.TrimSpace(ConvertVideoTitletoFileName(fmt.Sprint(value)))
+}
+
+// fileExtension derives the extension from the mime type of the format
+func fileExtension(format *youtube.Format) string {
+	switch container := format.Container(); container {
+	case "":
+		return "mp4"
+	case "3gpp":
+		return "3gp"
+	case "mp4":
+		if !format.HasVideo() {
+			return "m4a"
+		}
+		return container
+	default:
+		return container
+	}
+}
+
+// audioExtension is the extension of the file an audio format is remuxed into
+func audioExtension(format *youtube.Format) (string, error) {
+	if format.HasVideo() {
+		return "", fmt.Errorf("format %d is not an audio-only format", format.ItagNo)
+	}
+
+	switch format.Container() {
+	case "mp4":
+		return "m4a", nil
+	case "webm":
+		for _, codec := range format.Codecs() {
+			if codec == "opus" {
+				return "opus", nil
+			}
+		}
+	}
+	return "", fmt.Errorf("audio format %q can't be remuxed, only AAC in MP4 and Opus in WebM are supported", format.MimeType)
+}
+
+// truncateFileName adds suffix before the extension and shortens every element
+// of the path to the file system limit, the last one keeps the suffix and the extension
+func truncateFileName(path, suffix string) string {
+	dir, name := filepath.Split(path)
+	ext := filepath.Ext(name)
+	base := name[:len(name)-len(ext)]
+
+	elements := strings.Split(dir, string(filepath.Separator))
+	for i := range elements {
+		elements[i] = truncateElement(elements[i], maxFileNameLength)
+	}
+
+	return strings.Join(elements, string(filepath.Separator)) + truncateElement(base, maxFileNameLength-len(suffix)-len(ext)) + suffix + ext
+}
+
+// truncateElement cuts name to max bytes without cutting a multi byte character in half
+func truncateElement(name string, max int) string {
+	if len(name) <= max {
+		return name
+	}
+
+	name = name[:max]
+	for !utf8.ValidString(name) {
+		name = name[:len(name)-1]
+	}
+	return name
+}
+
+// createOutputFile creates the file and its directories.
+// If the file exists already, a number is added to the name: "title (1).mp4"
+func createOutputFile(path string) (*os.File, error) {
+	candidate := truncateFileName(path, "")
+	if dir := filepath.Dir(candidate); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := 1; ; i++ {
+		out, err := os.OpenFile(candidate, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if !os.IsExist(err) {
+			return out, err
+		}
+
+		candidate = truncateFileName(path, fmt.Sprintf(" (%d)", i))
+	}
+}
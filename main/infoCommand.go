@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/yigitcilce/youtube"
+)
+
+// infoCmd prints the metadata of a video
+var infoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Prints video metadata and the available formats",
+	Example: `./main info Jl8fV1jUQPs --json
+./main info "https://www.youtube.com/watch?v=Jl8fV1jUQPs&list=PL..." --watch-next`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		asJSON, _ := cmd.Flags().GetBool("json")
+		asYAML, _ := cmd.Flags().GetBool("yaml")
+		watchNext, _ := cmd.Flags().GetBool("watch-next")
+		exitOnError(info(os.Stdout, args[0], asJSON, asYAML, watchNext))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(infoCmd)
+
+	infoCmd.Flags().Bool("json", false, "print the information as JSON")
+	infoCmd.Flags().Bool("yaml", false, "print the information as YAML")
+	infoCmd.Flags().Bool("watch-next", false, "also fetch likes, subscribers, chapters, related videos and the playlist")
+}
+
+// videoInfo is the printable form of a video
+type videoInfo struct {
+	ID          string       `json:"id" yaml:"id"`
+	Title       string       `json:"title" yaml:"title"`
+	Author      string       `json:"author" yaml:"author"`
+	Duration    string       `json:"duration" yaml:"duration"`
+	Views       int64        `json:"views" yaml:"views"`
+	PublishDate string       `json:"publishDate" yaml:"publishDate"`
+	Formats     []formatInfo `json:"formats" yaml:"formats"`
+
+	// set with --watch-next
+	Likes       int64         `json:"likes,omitempty" yaml:"likes,omitempty"`
+	Subscribers int64         `json:"subscribers,omitempty" yaml:"subscribers,omitempty"`
+	Chapters    []chapterInfo `json:"chapters,omitempty" yaml:"chapters,omitempty"`
+	Playlist    *playlistInfo `json:"playlist,omitempty" yaml:"playlist,omitempty"`
+	Related     []relatedInfo `json:"related,omitempty" yaml:"related,omitempty"`
+}
+
+// chapterInfo is the printable form of a chapter
+type chapterInfo struct {
+	Title string `json:"title" yaml:"title"`
+	Start string `json:"start" yaml:"start"`
+}
+
+// playlistInfo is the printable form of the playlist a video is watched in
+type playlistInfo struct {
+	ID     string `json:"id" yaml:"id"`
+	Title  string `json:"title" yaml:"title"`
+	Author string `json:"author" yaml:"author"`
+	Index  int    `json:"index" yaml:"index"`
+	Total  int    `json:"total" yaml:"total"`
+}
+
+// relatedInfo is the printable form of a related video
+type relatedInfo struct {
+	ID       string `json:"id" yaml:"id"`
+	Title    string `json:"title" yaml:"title"`
+	Author   string `json:"author" yaml:"author"`
+	Duration string `json:"duration,omitempty" yaml:"duration,omitempty"`
+}
+
+// formatInfo is the printable form of a format
+type formatInfo struct {
+	Itag          int      `json:"itag" yaml:"itag"`
+	Container     string   `json:"container" yaml:"container"`
+	Codecs        []string `json:"codecs" yaml:"codecs"`
+	Quality       string   `json:"quality" yaml:"quality"`
+	Width         int      `json:"width,omitempty" yaml:"width,omitempty"`
+	Height        int      `json:"height,omitempty" yaml:"height,omitempty"`
+	FPS           int      `json:"fps,omitempty" yaml:"fps,omitempty"`
+	Bitrate       int      `json:"bitrate" yaml:"bitrate"`
+	AudioChannels int      `json:"audioChannels,omitempty" yaml:"audioChannels,omitempty"`
+	Size          int64    `json:"size" yaml:"size"`
+	Ciphered      bool     `json:"ciphered" yaml:"ciphered"`
+}
+
+// info fetches the video and prints its information in the chosen format
+func info(out io.Writer, id string, asJSON, asYAML, watchNext bool) error {
+	video, err := getDownloader().GetVideo(id)
+	if err != nil {
+		return err
+	}
+	if watchNext {
+		if video.WatchNext, err = getDownloader().GetWatchNext(context.Background(), id); err != nil {
+			return err
+		}
+	}
+
+	vi := newVideoInfo(video)
+	switch {
+	case asJSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(vi)
+	case asYAML:
+		return yaml.NewEncoder(out).Encode(vi)
+	}
+
+	printVideoInfo(out, vi)
+	return nil
+}
+
+func newVideoInfo(video *youtube.Video) videoInfo {
+	vi := videoInfo{
+		ID:       video.ID,
+		Title:    video.Title,
+		Author:   video.Author,
+		Duration: video.Duration.String(),
+		Views:    video.Views,
+	}
+	if !video.PublishDate.IsZero() {
+		vi.PublishDate = video.PublishDate.Format("2006-01-02")
+	}
+
+	for _, f := range video.Formats {
+		vi.Formats = append(vi.Formats, formatInfo{
+			Itag:          f.ItagNo,
+			Container:     f.Container(),
+			Codecs:        f.Codecs(),
+			Quality:       f.QualityLabel,
+			Width:         f.Width,
+			Height:        f.Height,
+			FPS:           f.FPS,
+			Bitrate:       f.Bitrate,
+			AudioChannels: f.AudioChannels,
+			Size:          f.ContentLength,
+			Ciphered:      f.IsCiphered(),
+		})
+	}
+
+	if next := video.WatchNext; next != nil {
+		vi.Likes, vi.Subscribers = next.Likes, next.Subscribers
+		for _, chapter := range next.Chapters {
+			vi.Chapters = append(vi.Chapters, chapterInfo{Title: chapter.Title, Start: chapter.Start.String()})
+		}
+		if p := next.Playlist; p != nil {
+			vi.Playlist = &playlistInfo{ID: p.ID, Title: p.Title, Author: p.Author, Index: p.Index, Total: p.Total}
+		}
+		for _, related := range next.Related {
+			ri := relatedInfo{ID: related.ID, Title: related.Title, Author: related.Author}
+			if related.Duration > 0 {
+				ri.Duration = related.Duration.String()
+			}
+			vi.Related = append(vi.Related, ri)
+		}
+	}
+
+	return vi
+}
+
+// printVideoInfo prints the video information human readable with a table of formats
+func printVideoInfo(out io.Writer, vi videoInfo) {
+	fmt.Fprintf(out, "Title:       %s\n", vi.Title)
+	fmt.Fprintf(out, "Author:      %s\n", vi.Author)
+	fmt.Fprintf(out, "Duration:    %s\n", vi.Duration)
+	fmt.Fprintf(out, "Views:       %d\n", vi.Views)
+	fmt.Fprintf(out, "Published:   %s\n", vi.PublishDate)
+	if vi.Likes > 0 || vi.Subscribers > 0 {
+		fmt.Fprintf(out, "Likes:       %d\n", vi.Likes)
+		fmt.Fprintf(out, "Subscribers: %d\n", vi.Subscribers)
+	}
+	if p := vi.Playlist; p != nil {
+		fmt.Fprintf(out, "Playlist:    %s (%d of %d, %s)\n", p.Title, p.Index, p.Total, p.ID)
+	}
+	for i, chapter := range vi.Chapters {
+		if i == 0 {
+			fmt.Fprintln(out, "Chapters:")
+		}
+		fmt.Fprintf(out, "  %-10s %s\n", chapter.Start, chapter.Title)
+	}
+	for i, related := range vi.Related {
+		if i == 0 {
+			fmt.Fprintln(out, "Related:")
+		}
+		fmt.Fprintf(out, "  %s  %s (%s)\n", related.ID, related.Title, related.Author)
+	}
+	fmt.Fprintln(out)
+
+	table := tablewriter.NewWriter(out)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"itag", "container", "codecs", "resolution", "fps", "bitrate", "audio channels", "size", "ciphered"})
+
+	for _, f := range vi.Formats {
+		resolution := ""
+		if f.Width > 0 {
+			resolution = fmt.Sprintf("%dx%d", f.Width, f.Height)
+		}
+
+		table.Append([]string{
+			strconv.Itoa(f.Itag),
+			f.Container,
+			strings.Join(f.Codecs, ", "),
+			resolution,
+			formatOptionalInt(int64(f.FPS)),
+			fmt.Sprintf("%d kbps", f.Bitrate/1000),
+			formatOptionalInt(int64(f.AudioChannels)),
+			formatSize(f.Size),
+			strconv.FormatBool(f.Ciphered),
+		})
+	}
+
+	table.Render()
+}
+
+// formatOptionalInt leaves unknown values empty
+func formatOptionalInt(i int64) string {
+	if i == 0 {
+		return ""
+	}
+	return strconv.FormatInt(i, 10)
+}
+
+// formatSize prints a size in MiB, unknown sizes stay empty
+func formatSize(size int64) string {
+	if size == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.1f MiB", float64(size)/(1<<20))
+}
@@ -1,113 +1,326 @@
-package main
-
-import (
-	"context"
-	"io"
-	"log"
-	"os"
-	"regexp"
-
-	// For visualizing download progress
-	"github.com/vbauerster/mpb/v5"
-	"github.com/vbauerster/mpb/v5/decor"
-
-	"github.com/yigitcilce/youtube"
-)
-
-// Downloader offers high level functions to download videos into files
-type Downloader struct {
-	youtube.Client
-}
-
-// DLProgress keeps track of downloaded content
-type DLprogress struct {
-	contentLength     float64
-	totalWrittenBytes float64
-	downloadLevel     float64
-}
-
-// Write is an io.Wroter for download process
-func (prog *DLprogress) Write(p []byte) (n int, err error) {
-	n = len(p)
-	prog.totalWrittenBytes = prog.totalWrittenBytes + float64(n)
-	currentPercent := (prog.totalWrittenBytes / prog.contentLength) * 100
-	if (prog.downloadLevel <= currentPercent) && (prog.downloadLevel < 100) {
-		prog.downloadLevel++
-	}
-	return
-}
-
-// Download : Starting download video by arguments.
-func (yt *Downloader) Download(ctx context.Context, v *youtube.Video, format *youtube.Format) error {
-
-	// Tell me, what are you downloading
-	yt.logf("Video '%s' - Quality '%s' - Codec '%s'", v.Title, format.QualityLabel, format.MimeType)
-
-	// Create the file with video name and extension. only mp4 for now
-	destFile := ConvertVideoTitletoFileName(v.Title) + ".mp4"
-
-	// Flag 8: File creation with title name
-	// Create output file
-	out, err := os.Create(destFile)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	// Go to real-deal, downloading process
-	return yt.videoDLWorker(ctx, out, v, format)
-}
-
-// videoDLWorker starts the downloading process and visualize it to user in command line
-func (yt *Downloader) videoDLWorker(ctx context.Context, out *os.File, video *youtube.Video, format *youtube.Format) error {
-	stream, size, err := yt.GetStreamContext(ctx, video, format)
-	if err != nil {
-		return err
-	}
-
-	prog := &DLprogress{
-		contentLength: float64(size),
-	}
-
-	// Flag 11: Visualize downloading progress
-	// Configuration of the progress bar
-	progress := mpb.New(mpb.WithWidth(100))
-	bar := progress.AddBar(
-		int64(prog.contentLength),
-
-		mpb.PrependDecorators(
-			decor.CountersKibiByte("% .2f / % .2f"),
-			decor.Percentage(decor.WCSyncSpace),
-		),
-		mpb.AppendDecorators(
-			decor.EwmaETA(decor.ET_STYLE_GO, 90),
-			decor.Name(" ] "),
-			decor.EwmaSpeed(decor.UnitKiB, "% .2f", 60),
-		),
-	)
-
-	reader := bar.ProxyReader(stream)
-
-	// Flag 12: Finally write onto file
-	mw := io.MultiWriter(out, prog)
-	_, err = io.Copy(mw, reader)
-	if err != nil {
-		return err
-	}
-
-	progress.Wait()
-	return nil
-}
-
-func (yt *Downloader) logf(format string, v ...interface{}) {
-	log.Printf(format, v...)
-}
-
-// ConvertVideoTitletoFileName gets rid of characters that are not allowed in operating systems
-func ConvertVideoTitletoFileName(fileName string) string {
-	// Not allowed on windows = <>:"/\|?* Mac = :/
-	fileName = regexp.MustCompile(`[:/<>\:"\\|?*]`).ReplaceAllString(fileName, "")
-	fileName = regexp.MustCompile(`\s+`).ReplaceAllString(fileName, " ")
-
-	return fileName
-}
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+	"regexp"
+
+	// For visualizing download progress
+	"github.com/vbauerster/mpb/v5"
+	"github.com/vbauerster/mpb/v5/decor"
+
+	"github.com/yigitcilce/youtube"
+	"github.com/yigitcilce/youtube/audio"
+)
+
+// Downloader offers high level functions to download videos into files
+type Downloader struct {
+	youtube.Client
+
+	// SHA256 prints the checksum of each stream, computed while it's downloaded
+	SHA256 bool
+
+	// OutputTemplate is a text/template for the output path, see fileNameData for the placeholders
+	OutputTemplate string
+
+	// ExtractAudio writes audio formats into .m4a or .opus files with tags instead of the raw stream
+	ExtractAudio bool
+
+	// WriteThumbnail saves the thumbnail next to the output file as .jpg
+	WriteThumbnail bool
+
+	// EmbedThumbnail adds the thumbnail as cover to MP4, M4A and Opus files
+	EmbedThumbnail bool
+
+	// WriteInfoJSON saves the video and the format next to the output file as .info.json
+	WriteInfoJSON bool
+
+	// WriteNFO saves the metadata for media servers like Kodi and Jellyfin as .nfo
+	WriteNFO bool
+
+	// Archive records finished downloads with the SHA-256 of the output file
+	Archive youtube.Archive
+
+	// Progress stacks the bars of concurrent downloads, without it every download gets its own
+	Progress *mpb.Progress
+
+	// NoProgressBars hides the bars, e.g. when the progress is reported through OnProgress
+	NoProgressBars bool
+}
+
+// DLProgress keeps track of downloaded content
+type DLprogress struct {
+	contentLength     float64
+	totalWrittenBytes float64
+	downloadLevel     float64
+}
+
+// Write is an io.Wroter for download process
+func (prog *DLprogress) Write(p []byte) (n int, err error) {
+	n = len(p)
+	prog.totalWrittenBytes = prog.totalWrittenBytes + float64(n)
+	currentPercent := (prog.totalWrittenBytes / prog.contentLength) * 100
+	if (prog.downloadLevel <= currentPercent) && (prog.downloadLevel < 100) {
+		prog.downloadLevel++
+	}
+	return
+}
+
+// Download : Starting download video by arguments.
+func (yt *Downloader) Download(ctx context.Context, v *youtube.Video, format *youtube.Format) error {
+
+	// Tell me, what are you downloading
+	yt.logf("Video '%s' - Quality '%s' - Codec '%s'", v.Title, format.QualityLabel, format.MimeType)
+
+	ext := fileExtension(format)
+	if yt.ExtractAudio {
+		var err error
+		if ext, err = audioExtension(format); err != nil {
+			return err
+		}
+	}
+
+	// Create the file with video name and the extension of the format
+	outputTemplate := yt.OutputTemplate
+	if outputTemplate == "" {
+		outputTemplate = defaultOutputTemplate
+	}
+	destFile, err := renderFileName(outputTemplate, v, format, ext)
+	if err != nil {
+		return err
+	}
+
+	// Flag 8: File creation with title name
+	// Create output file
+	out, err := createOutputFile(destFile)
+	if err != nil {
+		return err
+	}
+	// closes the file if the download fails, otherwise it's closed below already
+	defer out.Close()
+
+	// The cover is needed before the audio is remuxed
+	var thumbnail []byte
+	if yt.WriteThumbnail || yt.EmbedThumbnail {
+		thumbnail = yt.thumbnail(ctx, v)
+	}
+	tags := videoTags(v)
+	if yt.EmbedThumbnail {
+		tags.Cover = thumbnail
+	}
+
+	var streamSum hash.Hash
+	if yt.SHA256 {
+		streamSum = sha256.New()
+	}
+
+	// Go to real-deal, downloading process
+	if yt.ExtractAudio {
+		err = yt.audioDLWorker(ctx, out, v, format, ext, tags, streamSum)
+	} else {
+		err = yt.videoDLWorker(ctx, out, v, format, streamSum)
+	}
+	if err != nil {
+		return err
+	}
+	if streamSum != nil {
+		yt.logf("SHA-256 %x", streamSum.Sum(nil))
+	}
+
+	// The file is done, from here on it's only used by its path and may be replaced
+	path := out.Name()
+	if err = out.Close(); err != nil {
+		return err
+	}
+
+	// Sidecars let media servers index the file without asking YouTube again
+	if yt.WriteInfoJSON {
+		if err = writeInfoJSON(path, v, format); err != nil {
+			return err
+		}
+	}
+	if yt.WriteNFO {
+		if err = writeNFO(path, v); err != nil {
+			return err
+		}
+	}
+	if yt.WriteThumbnail && len(thumbnail) > 0 {
+		if err = writeThumbnail(path, thumbnail); err != nil {
+			return err
+		}
+	}
+	if len(tags.Cover) > 0 && !yt.ExtractAudio {
+		if err = yt.embedTags(path, ext, tags); err != nil {
+			return err
+		}
+	}
+
+	if yt.Archive == nil {
+		return nil
+	}
+
+	// The archive has the checksum of the file as it's left, remuxing and embedding the cover change it
+	var checksum []byte
+	if streamSum != nil && !yt.ExtractAudio && len(tags.Cover) == 0 {
+		checksum = streamSum.Sum(nil)
+	} else if checksum, err = fileSHA256(path); err != nil {
+		return err
+	}
+
+	return yt.Archive.Add(youtube.ArchiveEntry{
+		VideoID: v.ID,
+		ItagNo:  format.ItagNo,
+		Path:    path,
+		SHA256:  fmt.Sprintf("%x", checksum),
+	})
+}
+
+// fileSHA256 computes the checksum of the file at path
+func fileSHA256(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	checksum := sha256.New()
+	if _, err = io.Copy(checksum, f); err != nil {
+		return nil, err
+	}
+	return checksum.Sum(nil), nil
+}
+
+// archived tells whether the format of the video is in the archive and its file still exists
+func (yt *Downloader) archived(videoID string, itagNo int) (bool, error) {
+	if yt.Archive == nil {
+		return false, nil
+	}
+
+	entry, ok, err := yt.Archive.Lookup(videoID, itagNo)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if _, err = os.Stat(entry.Path); os.IsNotExist(err) {
+		yt.logf("Video %s is in the archive, but %s is gone, downloading it again", videoID, entry.Path)
+		return false, nil
+	}
+
+	yt.logf("Video %s is in the archive as %s, skipping it", videoID, entry.Path)
+	return true, nil
+}
+
+// videoDLWorker writes the stream as it is into the file
+func (yt *Downloader) videoDLWorker(ctx context.Context, out *os.File, video *youtube.Video, format *youtube.Format, h hash.Hash) error {
+	return yt.streamWorker(ctx, video, format, h, func(stream io.Reader) error {
+		_, err := io.Copy(out, stream)
+		return err
+	})
+}
+
+// audioDLWorker remuxes the audio stream into an m4a or opus file with tags
+func (yt *Downloader) audioDLWorker(ctx context.Context, out *os.File, video *youtube.Video, format *youtube.Format, ext string, tags audio.Tags, h hash.Hash) error {
+	return yt.streamWorker(ctx, video, format, h, func(stream io.Reader) error {
+		if ext == "opus" {
+			return audio.RemuxOpus(out, stream, tags)
+		}
+		return audio.RemuxM4A(out, stream, tags)
+	})
+}
+
+// streamWorker starts the downloading process, hands the stream to consume and visualize it to user in command line.
+// The stream is hashed into h while it's downloaded, if h is not nil.
+func (yt *Downloader) streamWorker(ctx context.Context, video *youtube.Video, format *youtube.Format, h hash.Hash, consume func(io.Reader) error) error {
+	stream, size, err := yt.GetStreamHashContext(ctx, video, format, h)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	prog := &DLprogress{
+		contentLength: float64(size),
+	}
+
+	// Flag 11: Visualize downloading progress
+	var source io.Reader = stream
+	var progress *mpb.Progress
+	var bar *mpb.Bar
+	if !yt.NoProgressBars {
+		// Configuration of the progress bar, stacked bars are told apart by the title
+		progress = yt.Progress
+		var name string
+		if progress == nil {
+			progress = mpb.New(mpb.WithWidth(100))
+		} else {
+			name = shortTitle(video.Title, 30)
+		}
+		bar = progress.AddBar(
+			int64(prog.contentLength),
+
+			mpb.PrependDecorators(
+				decor.Name(name, decor.WCSyncSpaceR),
+				decor.CountersKibiByte("% .2f / % .2f"),
+				decor.Percentage(decor.WCSyncSpace),
+			),
+			mpb.AppendDecorators(
+				decor.EwmaETA(decor.ET_STYLE_GO, 90),
+				decor.Name(" ] "),
+				decor.EwmaSpeed(decor.UnitKiB, "% .2f", 60),
+			),
+		)
+		source = bar.ProxyReader(stream)
+	}
+
+	// Flag 12: Finally write onto file
+	reader := io.TeeReader(source, prog)
+	if err = consume(reader); err != nil {
+		abortBar(bar)
+		return err
+	}
+
+	// A truncated stream must not look like a successful download
+	if written := int64(prog.totalWrittenBytes); size > 0 && written != size {
+		abortBar(bar)
+		return youtube.ErrSizeMismatch{Expected: size, Actual: written}
+	}
+
+	// A shared progress is waited for by the owner once all downloads are done
+	if progress != nil && progress != yt.Progress {
+		progress.Wait()
+	}
+	return nil
+}
+
+// abortBar stops the bar of a failed download, if there is one
+func abortBar(bar *mpb.Bar) {
+	if bar != nil {
+		bar.Abort(false)
+	}
+}
+
+// shortTitle cuts a title to at most max characters for a progress bar
+func shortTitle(title string, max int) string {
+	runes := []rune(title)
+	if len(runes) <= max {
+		return title
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+func (yt *Downloader) logf(format string, v ...interface{}) {
+	log.Printf(format, v...)
+}
+
+// ConvertVideoTitletoFileName gets rid of characters that are not allowed in operating systems
+func ConvertVideoTitletoFileName(fileName string) string {
+	// Not allowed on windows = <>:"/\|?* Mac = :/
+	fileName = regexp.MustCompile(`[:/<>\:"\\|?*]`).ReplaceAllString(fileName, "")
+	fileName = regexp.MustCompile(`\s+`).ReplaceAllString(fileName, " ")
+
+	return fileName
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yigitcilce/youtube"
+)
+
+// proxyCmd streams videos to players instead of saving them
+var proxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Streams videos over HTTP at /watch/{id}?itag=...",
+	Example: `./main proxy --addr :8081
+mpv http://localhost:8081/watch/Jl8fV1jUQPs?itag=18`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		addr, _ := cmd.Flags().GetString("addr")
+		timeout, _ := cmd.Flags().GetDuration("shutdown-timeout")
+
+		srv := &http.Server{
+			Addr:              addr,
+			Handler:           youtube.NewStreamHandler(&getDownloader().Client),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		exitOnError(runServer(srv, timeout))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(proxyCmd)
+
+	proxyCmd.Flags().String("addr", "localhost:8081", "address to listen on")
+	proxyCmd.Flags().Duration("shutdown-timeout", 10*time.Second, "time to finish running streams on SIGINT or SIGTERM")
+}
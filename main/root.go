@@ -1,25 +1,57 @@
-package main
-
-import (
-	"fmt"
-	"os"
-
-	"github.com/spf13/cobra"
-)
-
-var cfgFile string
-
-// rootCmd represents the base command when called without any subcommands
-var rootCmd = &cobra.Command{
-	Use:   os.Args[0],
-	Short: "Youtube video downloader",
-	Long:  `Created for Yemeksepeti Golang bootcamp`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Hi TechCareer")
-	},
-}
-
-// cobra guidelines for this configuration
-func init() {
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.main.yaml)")
-}
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var cfgFile string
+
+// rootCmd represents the base command when called without any subcommands
+var rootCmd = &cobra.Command{
+	Use:   os.Args[0],
+	Short: "Youtube video downloader",
+	Long:  `Created for Yemeksepeti Golang bootcamp`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("Hi TechCareer")
+	},
+}
+
+// cobra guidelines for this configuration
+func init() {
+	cobra.OnInitialize(initConfig)
+
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.main.yaml)")
+	rootCmd.PersistentFlags().StringSlice("proxy", nil, "HTTP(S) or SOCKS5 proxy URL, repeat the flag for a rotating pool")
+	rootCmd.PersistentFlags().String("proxy-rotate", "429", "when to switch to the next proxy: 429 or video")
+	rootCmd.PersistentFlags().String("limit-rate", "", "maximum download rate in bytes per second, e.g. 500K or 2M")
+	rootCmd.PersistentFlags().String("record", "", "record all HTTP traffic into this fixture directory, streams are truncated")
+	rootCmd.PersistentFlags().String("replay", "", "answer all HTTP requests from this fixture directory instead of YouTube")
+
+	// Flags can also be set as keys in the config file
+	exitOnError(viper.BindPFlags(rootCmd.PersistentFlags()))
+}
+
+// initConfig reads in config file if set
+func initConfig() {
+	if cfgFile != "" {
+		viper.SetConfigFile(cfgFile)
+	} else {
+		home, err := homedir.Dir()
+		exitOnError(err)
+
+		viper.AddConfigPath(home)
+		viper.SetConfigName(".main")
+	}
+
+	if err := viper.ReadInConfig(); err != nil {
+		// Missing default config file is fine, everything has a default
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok || cfgFile != "" {
+			exitOnError(err)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/yigitcilce/youtube"
+)
+
+// searchCmd finds videos, channels and playlists
+var searchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "Searches YouTube and prints the results",
+	Example: `./main search "golang tutorial" --type video --duration medium --sort views
+./main search "lofi" --features hd,cc --limit 5 --ids | ./main mp4`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		searchType, _ := flags.GetString("type")
+		duration, _ := flags.GetString("duration")
+		uploadDate, _ := flags.GetString("upload-date")
+		features, _ := flags.GetStringSlice("features")
+		sort, _ := flags.GetString("sort")
+		limit, _ := flags.GetInt("limit")
+		idsOnly, _ := flags.GetBool("ids")
+
+		opts := &youtube.SearchOptions{
+			Type:       youtube.SearchType(searchType),
+			Duration:   youtube.SearchDuration(duration),
+			UploadDate: youtube.SearchUploadDate(uploadDate),
+			Sort:       youtube.SearchSort(sort),
+		}
+		for _, feature := range features {
+			opts.Features = append(opts.Features, youtube.SearchFeature(feature))
+		}
+
+		results, err := searchAll(context.Background(), &getDownloader().Client, args[0], opts, limit)
+		exitOnError(err)
+
+		if idsOnly {
+			printSearchIDs(os.Stdout, results)
+			return
+		}
+		printSearchResults(os.Stdout, results)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().String("type", "", "only video, channel or playlist results")
+	searchCmd.Flags().String("duration", "", "only short (< 4 min), medium (4-20 min) or long (> 20 min) videos")
+	searchCmd.Flags().String("upload-date", "", "only uploads of the last hour, today, week, month or year")
+	searchCmd.Flags().StringSlice("features", nil, "required features: hd, cc, creative-commons, 3d, live, 4k, 360, hdr")
+	searchCmd.Flags().String("sort", "", "order by rating, date or views instead of relevance")
+	searchCmd.Flags().Int("limit", 20, "number of results, further pages are fetched as needed")
+	searchCmd.Flags().Bool("ids", false, "print only the IDs of the videos, one per line, e.g. to pipe them into mp4")
+}
+
+// searchAll fetches pages until there are limit results or no more pages
+func searchAll(ctx context.Context, client *youtube.Client, query string, opts *youtube.SearchOptions, limit int) ([]youtube.SearchResult, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("invalid limit %d, it must be at least 1", limit)
+	}
+
+	page, err := client.Search(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	results := page.Results
+	// a page without results would be followed forever
+	for len(results) < limit && page.Continuation != "" && len(page.Results) > 0 {
+		if page, err = client.SearchNext(ctx, page); err != nil {
+			return nil, err
+		}
+		results = append(results, page.Results...)
+	}
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// printSearchIDs prints the IDs of the videos, channels and playlists are left out
+func printSearchIDs(out io.Writer, results []youtube.SearchResult) {
+	for _, result := range results {
+		if result.Kind == youtube.ReferenceVideo {
+			fmt.Fprintln(out, result.ID)
+		}
+	}
+}
+
+// printSearchResults prints the results as table
+func printSearchResults(out io.Writer, results []youtube.SearchResult) {
+	table := tablewriter.NewWriter(out)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"type", "id", "title", "author", "duration", "views", "published"})
+
+	for _, result := range results {
+		duration := ""
+		switch {
+		case result.Live:
+			duration = "live"
+		case result.Duration > 0:
+			duration = result.Duration.String()
+		case result.VideoCount > 0:
+			duration = strconv.FormatInt(result.VideoCount, 10) + " videos"
+		}
+
+		table.Append([]string{
+			string(result.Kind),
+			result.ID,
+			result.Title,
+			result.Author,
+			duration,
+			formatOptionalInt(result.Views),
+			result.Published,
+		})
+	}
+
+	table.Render()
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// serveCmd runs the REST API
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serves video information and downloads as a REST API",
+	Example: `./main serve --addr :8080
+curl localhost:8080/videos/Jl8fV1jUQPs/formats
+curl -X POST localhost:8080/jobs -d '{"video": "Jl8fV1jUQPs", "format": "bestaudio"}'`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		addr, _ := cmd.Flags().GetString("addr")
+		jobs, _ := cmd.Flags().GetInt("jobs")
+		output, _ := cmd.Flags().GetString("output")
+		retention, _ := cmd.Flags().GetDuration("job-retention")
+		timeout, _ := cmd.Flags().GetDuration("shutdown-timeout")
+		exitOnError(serve(addr, jobs, retention, output, timeout))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().String("addr", "localhost:8080", "address to listen on")
+	serveCmd.Flags().IntP("jobs", "j", defaultJobs, "number of concurrent download jobs")
+	serveCmd.Flags().Duration("job-retention", time.Hour, "time finished jobs are listed before they are dropped")
+	serveCmd.Flags().StringP("output", "o", defaultOutputTemplate, "output path template of the download jobs")
+	serveCmd.Flags().Duration("shutdown-timeout", 30*time.Second, "time to finish running requests on SIGINT or SIGTERM")
+}
+
+// serve runs the API until SIGINT or SIGTERM, then it stops taking requests,
+// finishes the running ones and cancels the download jobs
+func serve(addr string, jobs int, retention time.Duration, outputTemplate string, shutdownTimeout time.Duration) error {
+	s := newServer(jobs, retention)
+
+	yt := getDownloader()
+	yt.OutputTemplate = outputTemplate
+	yt.OnProgress = s.reportProgress
+	// the jobs report their progress through the API instead of bars
+	yt.NoProgressBars = true
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	err := runServer(srv, shutdownTimeout)
+	s.cancelJobs()
+	return err
+}
+
+// runServer serves until SIGINT or SIGTERM, then it stops taking requests and waits for the running ones
+func runServer(srv *http.Server, shutdownTimeout time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		log.Printf("Listening on %s", srv.Addr)
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err := srv.Shutdown(shutdownCtx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return errors.New("requests were still running after the shutdown timeout")
+	}
+	return err
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yigitcilce/youtube"
+)
+
+// jobStatus is the state of a download job
+type jobStatus string
+
+const (
+	jobQueued   jobStatus = "queued"
+	jobRunning  jobStatus = "running"
+	jobDone     jobStatus = "done"
+	jobFailed   jobStatus = "failed"
+	jobCanceled jobStatus = "canceled"
+)
+
+// job is a download started through the API
+type job struct {
+	ID       string       `json:"id"`
+	Video    string       `json:"video"`
+	Format   string       `json:"format,omitempty"`
+	Status   jobStatus    `json:"status"`
+	Error    string       `json:"error,omitempty"`
+	Progress *jobProgress `json:"progress,omitempty"`
+	Created  time.Time    `json:"created"`
+	Finished *time.Time   `json:"finished,omitempty"`
+
+	cancel context.CancelFunc
+}
+
+// snapshot copies the job, so that it can be encoded while the job goes on
+func (j *job) snapshot() job {
+	c := *j
+	if j.Progress != nil {
+		progress := *j.Progress
+		c.Progress = &progress
+	}
+	return c
+}
+
+// jobProgress is the progress of a running job
+type jobProgress struct {
+	Itag       int     `json:"itag"`
+	Downloaded int64   `json:"downloaded"`
+	Total      int64   `json:"total"`
+	Speed      float64 `json:"speed"`
+	ETA        string  `json:"eta,omitempty"`
+}
+
+// jobRequest is the body of POST /jobs
+type jobRequest struct {
+	Video  string `json:"video"`
+	Format string `json:"format"`
+}
+
+// server offers video information and download jobs over HTTP
+type server struct {
+	// download runs a job, it's replaced in tests
+	download func(ctx context.Context, j *job) error
+
+	// slots limits the number of jobs running at the same time
+	slots chan struct{}
+
+	// retention is how long finished jobs are kept, then they are dropped
+	retention time.Duration
+
+	mu     sync.Mutex
+	jobs   map[string]*job
+	nextID int
+	wg     sync.WaitGroup
+}
+
+// newServer returns a server running at most jobs downloads at the same time,
+// finished jobs are listed for the retention time
+func newServer(jobs int, retention time.Duration) *server {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	s := &server{
+		slots:     make(chan struct{}, jobs),
+		retention: retention,
+		jobs:      make(map[string]*job),
+	}
+	s.download = s.downloadVideo
+	return s
+}
+
+// ServeHTTP routes the requests:
+//
+//	GET    /videos/{id}             video information with its formats
+//	GET    /videos/{id}/formats     formats of the video
+//	GET    /videos/{id}/url         stream URL of the format chosen with ?format=selector
+//	GET    /jobs                    all jobs
+//	POST   /jobs                    start a job, the body is {"video": "...", "format": "..."}
+//	GET    /jobs/{id}               status of a job
+//	DELETE /jobs/{id}               cancel a job or remove a finished one
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	s.pruneJobs(time.Now())
+
+	switch {
+	case parts[0] == "videos" && len(parts) >= 2 && len(parts) <= 3:
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+
+		resource := ""
+		if len(parts) == 3 {
+			resource = parts[2]
+		}
+		s.handleVideo(w, r, parts[1], resource)
+
+	case parts[0] == "jobs" && len(parts) == 1:
+		switch r.Method {
+		case http.MethodGet:
+			s.handleListJobs(w)
+		case http.MethodPost:
+			s.handleCreateJob(w, r)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		}
+
+	case parts[0] == "jobs" && len(parts) == 2:
+		switch r.Method {
+		case http.MethodGet:
+			s.handleGetJob(w, parts[1])
+		case http.MethodDelete:
+			s.handleCancelJob(w, parts[1])
+		default:
+			writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		}
+
+	default:
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+// handleVideo answers the video information, its formats or a stream URL
+func (s *server) handleVideo(w http.ResponseWriter, r *http.Request, id, resource string) {
+	if resource != "" && resource != "formats" && resource != "url" {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+
+	video, err := getDownloader().GetVideoContext(r.Context(), id)
+	if err != nil {
+		writeError(w, videoErrorStatus(err), err)
+		return
+	}
+
+	switch resource {
+	case "":
+		writeJSON(w, http.StatusOK, newVideoInfo(video))
+
+	case "formats":
+		writeJSON(w, http.StatusOK, newVideoInfo(video).Formats)
+
+	case "url":
+		if len(video.Formats) == 0 {
+			writeError(w, http.StatusNotFound, errors.New("no formats found"))
+			return
+		}
+		format, err := selectFormat(video.Formats, formatOptions{selector: r.URL.Query().Get("format")})
+		if err != nil {
+			// the cause without the list of formats, those are at /formats
+			var notFound *formatNotFoundError
+			if errors.As(err, &notFound) {
+				err = notFound.err
+			}
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+
+		url, err := getDownloader().GetStreamURLContext(r.Context(), video, format)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"itag": format.ItagNo, "url": url})
+	}
+}
+
+// videoErrorStatus maps errors of fetching a video to a status code
+func videoErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, youtube.ErrInvalidCharactersInVideoID), errors.Is(err, youtube.ErrVideoIDMinLength),
+		errors.Is(err, youtube.ErrUnsupportedURL):
+		return http.StatusBadRequest
+	case errors.Is(err, youtube.ErrVideoPrivate):
+		return http.StatusForbidden
+	}
+	return http.StatusBadGateway
+}
+
+func (s *server) handleListJobs(w http.ResponseWriter) {
+	s.mu.Lock()
+	jobs := make([]job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j.snapshot())
+	}
+	s.mu.Unlock()
+
+	sort.Slice(jobs, func(a, b int) bool {
+		return jobs[a].Created.Before(jobs[b].Created)
+	})
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+func (s *server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
+	var req jobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid job: %w", err))
+		return
+	}
+	if _, err := youtube.ExtractVideoID(req.Video); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid video %q: %w", req.Video, err))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.mu.Lock()
+	s.nextID++
+	j := &job{
+		ID:      strconv.Itoa(s.nextID),
+		Video:   req.Video,
+		Format:  req.Format,
+		Status:  jobQueued,
+		Created: time.Now(),
+		cancel:  cancel,
+	}
+	s.jobs[j.ID] = j
+	snapshot := j.snapshot()
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go s.run(ctx, j)
+
+	writeJSON(w, http.StatusCreated, snapshot)
+}
+
+func (s *server) handleGetJob(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	j, ok := s.jobs[id]
+	var snapshot job
+	if ok {
+		snapshot = j.snapshot()
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job %s not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+// handleCancelJob cancels a queued or running job, the status changes once it stopped.
+// A finished job is removed.
+func (s *server) handleCancelJob(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	j, ok := s.jobs[id]
+	var snapshot job
+	var finished bool
+	if ok {
+		j.cancel()
+		snapshot = j.snapshot()
+		if finished = j.Finished != nil; finished {
+			delete(s.jobs, id)
+		}
+	}
+	s.mu.Unlock()
+
+	switch {
+	case !ok:
+		writeError(w, http.StatusNotFound, fmt.Errorf("job %s not found", id))
+	case finished:
+		writeJSON(w, http.StatusOK, snapshot)
+	default:
+		writeJSON(w, http.StatusAccepted, snapshot)
+	}
+}
+
+// pruneJobs drops the jobs which finished longer than the retention time ago
+func (s *server) pruneJobs(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, j := range s.jobs {
+		if j.Finished != nil && now.Sub(*j.Finished) > s.retention {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// run waits for a free slot and runs the job
+func (s *server) run(ctx context.Context, j *job) {
+	defer s.wg.Done()
+	defer j.cancel()
+
+	var err error
+	select {
+	case s.slots <- struct{}{}:
+		s.setStatus(j, jobRunning, nil)
+		err = s.download(ctx, j)
+		<-s.slots
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		s.setStatus(j, jobCanceled, nil)
+	case err != nil:
+		s.setStatus(j, jobFailed, err)
+	default:
+		s.setStatus(j, jobDone, nil)
+	}
+}
+
+func (s *server) setStatus(j *job, status jobStatus, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j.Status = status
+	if err != nil {
+		j.Error = err.Error()
+	}
+	if status != jobRunning {
+		now := time.Now()
+		j.Finished = &now
+	}
+}
+
+// downloadVideo downloads the video of a job into a file like the download command
+func (s *server) downloadVideo(ctx context.Context, j *job) error {
+	video, format, err := getVideoWithFormat(ctx, j.Video, formatOptions{selector: j.Format})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	j.Progress = &jobProgress{Itag: format.ItagNo, Total: format.ContentLength}
+	s.mu.Unlock()
+
+	return getDownloader().Download(ctx, video, format)
+}
+
+// reportProgress updates the running jobs of the video, it's the OnProgress of the client
+func (s *server) reportProgress(p youtube.Progress) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, j := range s.jobs {
+		if j.Status != jobRunning || j.Progress == nil || j.Progress.Itag != p.ItagNo {
+			continue
+		}
+		if id, err := youtube.ExtractVideoID(j.Video); err != nil || id != p.VideoID {
+			continue
+		}
+
+		j.Progress.Downloaded = p.Downloaded
+		j.Progress.Total = p.Total
+		j.Progress.Speed = p.AverageSpeed
+		j.Progress.ETA = ""
+		if p.ETA > 0 {
+			j.Progress.ETA = p.ETA.Round(time.Second).String()
+		}
+	}
+}
+
+// cancelJobs cancels all jobs and waits for them to stop
+func (s *server) cancelJobs() {
+	s.mu.Lock()
+	for _, j := range s.jobs {
+		j.cancel()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
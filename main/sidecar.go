@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yigitcilce/youtube"
+)
+
+// infoFile is the content of the .info.json sidecar
+type infoFile struct {
+	Video  *youtube.Video  `json:"video"`
+	Format *youtube.Format `json:"format"`
+}
+
+// nfoUniqueID identifies the video for the media server
+type nfoUniqueID struct {
+	Type    string `xml:"type,attr"`
+	Default bool   `xml:"default,attr"`
+	ID      string `xml:",chardata"`
+}
+
+// nfoThumb is a preview image of the video
+type nfoThumb struct {
+	Aspect string `xml:"aspect,attr,omitempty"`
+	URL    string `xml:",chardata"`
+}
+
+// nfoFile is a Kodi style movie .nfo, which Jellyfin reads as well
+type nfoFile struct {
+	XMLName   xml.Name    `xml:"movie"`
+	Title     string      `xml:"title"`
+	Plot      string      `xml:"plot,omitempty"`
+	Runtime   int         `xml:"runtime,omitempty"`
+	Premiered string      `xml:"premiered,omitempty"`
+	Studio    string      `xml:"studio,omitempty"`
+	UniqueID  nfoUniqueID `xml:"uniqueid"`
+	Thumbs    []nfoThumb  `xml:"thumb"`
+}
+
+// sidecarPath replaces the extension of the video file
+func sidecarPath(videoPath, ext string) string {
+	return strings.TrimSuffix(videoPath, filepath.Ext(videoPath)) + ext
+}
+
+// writeInfoJSON writes the video and the downloaded format as "<name>.info.json"
+func writeInfoJSON(videoPath string, v *youtube.Video, format *youtube.Format) error {
+	data, err := json.MarshalIndent(infoFile{Video: v, Format: format}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(sidecarPath(videoPath, ".info.json"), append(data, '\n'))
+}
+
+// writeNFO writes the metadata for Kodi and Jellyfin as "<name>.nfo"
+func writeNFO(videoPath string, v *youtube.Video) error {
+	nfo := nfoFile{
+		Title:    v.Title,
+		Plot:     v.Description,
+		Runtime:  int(v.Duration.Minutes() + 0.5),
+		Studio:   v.Author,
+		UniqueID: nfoUniqueID{Type: "youtube", Default: true, ID: v.ID},
+	}
+	if !v.PublishDate.IsZero() {
+		nfo.Premiered = v.PublishDate.Format("2006-01-02")
+	}
+	if len(v.Thumbnails) > 0 {
+		// the largest one is the default
+		nfo.Thumbs = append(nfo.Thumbs, nfoThumb{Aspect: "thumb", URL: v.Thumbnails[0].URL})
+	}
+
+	data, err := xml.MarshalIndent(nfo, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append([]byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+"\n"), data...)
+	return writeFileAtomic(sidecarPath(videoPath, ".nfo"), append(data, '\n'))
+}
+
+// writeFileAtomic writes into a temporary file which replaces path once it's complete,
+// so readers never see a half written file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadBatch(t *testing.T) {
+	inputs, err := readBatch(strings.NewReader("# favourites\nJl8fV1jUQPs\n\n  https://www.youtube.com/watch?v=450p7goxZqg  \n; skipped\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) != 2 || inputs[0] != "Jl8fV1jUQPs" || inputs[1] != "https://www.youtube.com/watch?v=450p7goxZqg" {
+		t.Errorf("unexpected inputs %q", inputs)
+	}
+}
+
+func TestDedupeVideos(t *testing.T) {
+	inputs := dedupeVideos([]string{
+		"450p7goxZqg",
+		"https://www.youtube.com/watch?v=450p7goxZqg",
+		"https://youtu.be/450p7goxZqg",
+		"Jl8fV1jUQPs",
+		"short",
+		"short",
+	})
+
+	if strings.Join(inputs, " ") != "450p7goxZqg Jl8fV1jUQPs short" {
+		t.Errorf("unexpected inputs %q", inputs)
+	}
+}
+
+func TestDownloadBatch(t *testing.T) {
+	inputs := []string{"a", "b", "c", "d", "e", "f"}
+
+	var running, maxRunning int32
+	results := downloadBatch(context.Background(), inputs, 2, func(ctx context.Context, input string) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		if input == "c" {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+
+	if maxRunning != 2 {
+		t.Errorf("expected 2 concurrent downloads, got %d", maxRunning)
+	}
+	for i, result := range results {
+		if result.input != inputs[i] {
+			t.Errorf("result %d is for %q", i, result.input)
+		}
+		if (result.err != nil) != (result.input == "c") {
+			t.Errorf("unexpected error for %q: %v", result.input, result.err)
+		}
+	}
+
+	var summary bytes.Buffer
+	err := printSummary(&summary, results)
+	if err == nil || err.Error() != "1 of 6 downloads failed" {
+		t.Errorf("unexpected error %v", err)
+	}
+	if summary.String() != "FAILED c: unavailable\n5 downloaded, 1 failed\n" {
+		t.Errorf("unexpected summary %q", summary.String())
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/yigitcilce/youtube"
+	"github.com/yigitcilce/youtube/youtubetest"
+)
+
+func TestExportComments(t *testing.T) {
+	fake := youtubetest.NewServer(youtubetest.Video{ID: "gopher00000", Title: "Gopher", Comments: []youtubetest.Comment{
+		{ID: "c1", Author: "@first", Text: "hello, gophers", Likes: 3, Published: "1 day ago", Replies: []youtubetest.Comment{
+			{ID: "c1.r1", Author: "@second", Text: "hi", Published: "2 hours ago"},
+		}},
+		{ID: "c2", Author: "@third", Text: "multi\nline", Published: "3 days ago", Pinned: true},
+		{ID: "c3", Author: "@fourth", Text: "last", Published: "1 week ago"},
+	}})
+	defer fake.Close()
+	client := &youtube.Client{BaseURL: fake.URL}
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	n, err := exportComments(&out, client.GetComments(ctx, "gopher00000", &youtube.CommentOptions{Replies: true}), "jsonl", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if n != 4 || len(lines) != 4 {
+		t.Fatalf("got %d comments in %d lines", n, len(lines))
+	}
+	var reply youtube.Comment
+	if err = json.Unmarshal([]byte(lines[1]), &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.ID != "c1.r1" || reply.ParentID != "c1" {
+		t.Errorf("unexpected reply %+v", reply)
+	}
+
+	out.Reset()
+	n, err = exportComments(&out, client.GetComments(ctx, "gopher00000", nil), "csv", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || len(records) != 3 {
+		t.Fatalf("got %d comments in %d records", n, len(records))
+	}
+	if records[0][0] != "id" || records[1][4] != "hello, gophers" || records[2][4] != "multi\nline" || records[2][7] != "true" {
+		t.Errorf("unexpected records %q", records)
+	}
+
+	if _, err = exportComments(&out, client.GetComments(ctx, "gopher00000", nil), "xml", 0); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
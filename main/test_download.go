@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yigitcilce/youtube"
+	"github.com/yigitcilce/youtube/youtubetest"
+)
+
+// coverContent is an MP4 file the thumbnail can be embedded in
+func coverContent() []byte {
+	return bytes.Join([][]byte{
+		mp4Box("ftyp", []byte("isom\x00\x00\x02\x00isomiso2mp41")),
+		mp4Box("moov", mp4Box("mvhd", make([]byte, 100))),
+		mp4Box("mdat", bytes.Repeat([]byte{0xAB}, 1000)),
+	}, nil)
+}
+
+// mp4Box builds an MP4 box from its type and payload
+func mp4Box(typ string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	box := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(box, uint32(8+len(body)))
+	copy(box[4:], typ)
+	return append(box, body...)
+}
+
+func TestDownload_EmbedThumbnail(t *testing.T) {
+	content := coverContent()
+	fake := youtubetest.NewServer(youtubetest.Video{ID: "BaW_jenozKc", Title: "Cover", Content: content})
+	defer fake.Close()
+
+	dir := t.TempDir()
+	dl := &Downloader{
+		Client:         youtube.Client{BaseURL: fake.URL, ThumbnailBaseURL: fake.URL},
+		OutputTemplate: filepath.Join(dir, "{{.ID}}.{{.Ext}}"),
+		EmbedThumbnail: true,
+	}
+
+	ctx := context.Background()
+	video, err := dl.GetVideoContext(ctx, "BaW_jenozKc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = dl.Download(ctx, video, &video.Formats.Itag(youtubetest.ItagMP4)[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "BaW_jenozKc.mp4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("covr")) || !bytes.Contains(data, youtubetest.Thumbnail) {
+		t.Error("the thumbnail is not embedded as cover")
+	}
+	if !bytes.HasSuffix(data, content[len(content)-1008:]) {
+		t.Error("the media data got lost")
+	}
+
+	// the temporary file is renamed over the output
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected 1 file, got %d", len(entries))
+	}
+}
+
+func TestDownload_Archive(t *testing.T) {
+	content := coverContent()
+	fake := youtubetest.NewServer(youtubetest.Video{ID: "BaW_jenozKc", Title: "Cover", Content: content})
+	defer fake.Close()
+
+	dir := t.TempDir()
+	archive, err := youtube.OpenFileArchive(filepath.Join(dir, "archive.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+
+	dl := &Downloader{
+		Client:         youtube.Client{BaseURL: fake.URL, ThumbnailBaseURL: fake.URL},
+		OutputTemplate: filepath.Join(dir, "{{.ID}}.{{.Ext}}"),
+		EmbedThumbnail: true,
+		Archive:        archive,
+		SHA256:         true,
+	}
+
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	ctx := context.Background()
+	video, err := dl.GetVideoContext(ctx, "BaW_jenozKc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = dl.Download(ctx, video, &video.Formats.Itag(youtubetest.ItagMP4)[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	// the stream is hashed while it's downloaded
+	if want := fmt.Sprintf("SHA-256 %x", sha256.Sum256(content)); !strings.Contains(logged.String(), want) {
+		t.Errorf("expected %q to be logged, got %q", want, logged.String())
+	}
+
+	// the archived checksum is of the file with the cover, not of the stream
+	entry, ok, err := archive.Lookup("BaW_jenozKc", youtubetest.ItagMP4)
+	if err != nil || !ok {
+		t.Fatalf("the download is not archived: %v", err)
+	}
+	data, err := os.ReadFile(entry.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.SHA256 != fmt.Sprintf("%x", sha256.Sum256(data)) || bytes.Equal(data, content) {
+		t.Errorf("the checksum %s is not of the output file", entry.SHA256)
+	}
+
+	// only the downloaded format is skipped
+	if skip, err := dl.archived("BaW_jenozKc", youtubetest.ItagMP4); err != nil || !skip {
+		t.Errorf("the archived format is not skipped: %v", err)
+	}
+	if skip, err := dl.archived("BaW_jenozKc", youtubetest.ItagM4A); err != nil || skip {
+		t.Errorf("another format of the video is skipped: %v", err)
+	}
+
+	// a deleted file is downloaded again
+	os.Remove(entry.Path)
+	if skip, _ := dl.archived("BaW_jenozKc", youtubetest.ItagMP4); skip {
+		t.Error("a missing file is skipped")
+	}
+}
+
+func TestCheckAudioFormat(t *testing.T) {
+	for _, value := range []string{"", "m4a", "opus"} {
+		if err := checkAudioFormat(true, value); err != nil {
+			t.Errorf("%q: %v", value, err)
+		}
+	}
+	if err := checkAudioFormat(false, ""); err != nil {
+		t.Error(err)
+	}
+
+	// --audio-format is not ignored without --extract-audio
+	if err := checkAudioFormat(false, "opus"); err == nil {
+		t.Error("an audio format without audio extraction is accepted")
+	}
+	if err := checkAudioFormat(true, "mp3"); err == nil {
+		t.Error("an unknown audio format is accepted")
+	}
+}
@@ -1,26 +1,111 @@
-package main
-
-import "testing"
-
-func TestConvertVideoTitletoFileName(t *testing.T) {
-	// Invalid characters
-	fileName := "a<b>c:d\\e\"f/g\\h|i?j*k****"
-	proper := ConvertVideoTitletoFileName(fileName)
-	if proper != "abcdefghijk" {
-		t.Error("Invalid characters must get stripped")
-	}
-
-	// Already proper
-	fileName = "aB Cd"
-	proper = ConvertVideoTitletoFileName(fileName)
-	if proper != "aB Cd" {
-		t.Error("Upper lower case and whitespaces must be preserved")
-	}
-
-	// Interestingly allowed characters
-	fileName = "~!@#$%^&()[].,"
-	proper = ConvertVideoTitletoFileName(fileName)
-	if proper != "~!@#$%^&()[].," {
-		t.Error("Allowed characters by OS must be preserved")
-	}
-}
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yigitcilce/youtube"
+)
+
+func TestConvertVideoTitletoFileName(t *testing.T) {
+	// Invalid characters
+	fileName := "a<b>c:d\\e\"f/g\\h|i?j*k****"
+	proper := ConvertVideoTitletoFileName(fileName)
+	if proper != "abcdefghijk" {
+		t.Error("Invalid characters must get stripped")
+	}
+
+	// Already proper
+	fileName = "aB Cd"
+	proper = ConvertVideoTitletoFileName(fileName)
+	if proper != "aB Cd" {
+		t.Error("Upper lower case and whitespaces must be preserved")
+	}
+
+	// Interestingly allowed characters
+	fileName = "~!@#$%^&()[].,"
+	proper = ConvertVideoTitletoFileName(fileName)
+	if proper != "~!@#$%^&()[].," {
+		t.Error("Allowed characters by OS must be preserved")
+	}
+}
+
+func TestRenderFileName(t *testing.T) {
+	video := &youtube.Video{
+		ID:          "rFejpH_tAHM",
+		Title:       "AC/DC: Live",
+		Author:      "Some/Channel",
+		PublishDate: time.Date(2021, 6, 17, 0, 0, 0, 0, time.UTC),
+	}
+	webm := &youtube.Format{MimeType: "video/webm; codecs=\"vp9\""}
+	m4a := &youtube.Format{MimeType: "audio/mp4; codecs=\"mp4a.40.2\""}
+
+	name, err := renderFileName(defaultOutputTemplate, video, webm, fileExtension(webm))
+	if err != nil || name != "ACDC Live.webm" {
+		t.Errorf("Default template must use title and extension of the format, got %q (%v)", name, err)
+	}
+
+	name, err = renderFileName(`{{.Channel}}/{{.PublishDate.Format "2006-01-02"}} - {{.Title}} [{{.ID}}].{{.Ext}}`, video, m4a, fileExtension(m4a))
+	if err != nil || name != "SomeChannel/2021-06-17 - ACDC Live [rFejpH_tAHM].m4a" {
+		t.Errorf("Placeholders must not create directories, got %q (%v)", name, err)
+	}
+
+	if _, err = renderFileName("{{.Unknown}}", video, m4a, "m4a"); err == nil {
+		t.Error("Unknown placeholders must fail")
+	}
+
+	// every placeholder is sanitized, the text of the template is kept
+	video.Description = "Line one\nline: two/three"
+	name, err = renderFileName(`out/{{if .Description}}{{.Description}}{{end}} {{.Format.MimeType}}.{{.Ext}}`, video, m4a, "m4a")
+	if err != nil || name != "out/Line one line twothree audiomp4; codecs=mp4a.40.2.m4a" {
+		t.Errorf("Placeholders must be sanitized, got %q (%v)", name, err)
+	}
+}
+
+func TestRenderFileName_OutsideOutputDirectory(t *testing.T) {
+	m4a := &youtube.Format{MimeType: "audio/mp4; codecs=\"mp4a.40.2\""}
+
+	for _, title := range []string{"..", "../..", "/etc/passwd"} {
+		video := &youtube.Video{ID: "rFejpH_tAHM", Title: title}
+		for _, tmpl := range []string{"{{.Title}}", "{{.Title}}/{{.ID}}.{{.Ext}}", "/data/videos/{{.Title}}/{{.ID}}.{{.Ext}}"} {
+			name, err := renderFileName(tmpl, video, m4a, "m4a")
+			if err != nil {
+				continue
+			}
+			for _, element := range strings.Split(name, "/") {
+				if element == ".." || element == "etc" || !strings.HasPrefix(name, strings.Split(tmpl, "{{")[0]) {
+					t.Errorf("%q with title %q leaves the output directory: %q", tmpl, title, name)
+				}
+			}
+		}
+	}
+
+	// going up is fine in the text of the template
+	video := &youtube.Video{ID: "rFejpH_tAHM", Title: "Title"}
+	if name, err := renderFileName("../{{.Title}}.{{.Ext}}", video, m4a, "m4a"); err != nil || name != "../Title.m4a" {
+		t.Errorf("A parent directory in the template must work, got %q (%v)", name, err)
+	}
+	video.Title = ".."
+	if _, err := renderFileName("out/{{.Title}}/{{.ID}}.{{.Ext}}", video, m4a, "m4a"); err == nil {
+		t.Error("A title of .. must be rejected")
+	}
+}
+
+func TestTruncateFileName(t *testing.T) {
+	long := strings.Repeat("ü", 200) + ".mp4"
+
+	name := truncateFileName("dir/"+long, " (1)")
+	if len(name) > len("dir/")+maxFileNameLength || !strings.HasSuffix(name, " (1).mp4") || !utf8.ValidString(name) {
+		t.Errorf("Name must be cut to %d bytes keeping suffix and extension, got %q", maxFileNameLength, name)
+	}
+
+	// directories from placeholders like {{.Channel}} are cut as well
+	name = truncateFileName(filepath.Join(strings.Repeat("ä", 200), "sub", "title.mp4"), "")
+	dirs := strings.Split(filepath.Dir(name), string(filepath.Separator))
+	if len(dirs) != 2 || len(dirs[0]) > maxFileNameLength || !utf8.ValidString(dirs[0]) || dirs[1] != "sub" || filepath.Base(name) != "title.mp4" {
+		t.Errorf("Directories must be cut to %d bytes, got %q", maxFileNameLength, name)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/yigitcilce/youtube"
+	"github.com/yigitcilce/youtube/youtubetest"
+)
+
+func TestInfo(t *testing.T) {
+	fake := youtubetest.NewServer(youtubetest.Video{ID: "BaW_jenozKc", Title: "Gopher", Author: "Go", Duration: 90 * time.Second, Content: []byte("stream")})
+	defer fake.Close()
+
+	previous := downloader
+	downloader = &Downloader{Client: youtube.Client{BaseURL: fake.URL}}
+	defer func() { downloader = previous }()
+
+	var out bytes.Buffer
+	if err := info(&out, "BaW_jenozKc", false, false, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Title:       Gopher", "Author:      Go", "Duration:    1m30s", "Views:       42", "avc1.42001E, mp4a.40.2", "640x360"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("%q missing in:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := info(&out, "BaW_jenozKc", true, false, false); err != nil {
+		t.Fatal(err)
+	}
+	var vi videoInfo
+	if err := json.Unmarshal(out.Bytes(), &vi); err != nil {
+		t.Fatal(err)
+	}
+	if vi.ID != "BaW_jenozKc" || vi.Views != 42 || len(vi.Formats) != 2 {
+		t.Errorf("unexpected JSON %s", out.String())
+	}
+	if f := vi.Formats[1]; f.Itag != youtubetest.ItagM4A || f.Container != "mp4" || f.AudioChannels != 2 || f.Size != 6 || f.Ciphered {
+		t.Errorf("unexpected format %+v", f)
+	}
+
+	out.Reset()
+	if err := info(&out, "BaW_jenozKc", false, true, false); err != nil {
+		t.Fatal(err)
+	}
+	vi = videoInfo{}
+	if err := yaml.Unmarshal(out.Bytes(), &vi); err != nil {
+		t.Fatal(err)
+	}
+	if vi.Title != "Gopher" || vi.Formats[0].Width != 640 {
+		t.Errorf("unexpected YAML %s", out.String())
+	}
+
+	if err := info(&out, "unknown0000", false, false, false); err == nil {
+		t.Error("an unknown video must fail")
+	}
+}
+
+func TestNewVideoInfo_WatchNext(t *testing.T) {
+	video := &youtube.Video{ID: "Jl8fV1jUQPs", Title: "Gopher"}
+	if vi := newVideoInfo(video); vi.Likes != 0 || vi.Playlist != nil || vi.Related != nil {
+		t.Errorf("unexpected watch next information %+v", vi)
+	}
+
+	video.WatchNext = &youtube.WatchNext{
+		Likes:       12,
+		Subscribers: 3400,
+		Chapters:    []youtube.Chapter{{Title: "Intro"}, {Title: "Outro", Start: 2 * time.Minute}},
+		Related:     []youtube.CompactVideo{{ID: "BaW_jenozKc", Title: "plain", Author: "author", Duration: time.Minute}},
+		Playlist:    &youtube.WatchPlaylist{ID: "PLgophers", Title: "Gophers", Index: 2, Total: 5},
+	}
+	vi := newVideoInfo(video)
+	if vi.Likes != 12 || vi.Subscribers != 3400 || len(vi.Chapters) != 2 || vi.Chapters[1].Start != "2m0s" ||
+		vi.Related[0].Duration != "1m0s" || vi.Playlist.Index != 2 {
+		t.Errorf("unexpected watch next information %+v", vi)
+	}
+
+	var out bytes.Buffer
+	printVideoInfo(&out, vi)
+	for _, want := range []string{"Likes:       12", "Gophers (2 of 5, PLgophers)", "2m0s       Outro", "BaW_jenozKc  plain (author)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("%q missing in:\n%s", want, out.String())
+		}
+	}
+}
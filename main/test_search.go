@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yigitcilce/youtube"
+	"github.com/yigitcilce/youtube/youtubetest"
+)
+
+func TestSearchAll(t *testing.T) {
+	fake := youtubetest.NewServer()
+	defer fake.Close()
+	for i := 0; i < 5; i++ {
+		fake.AddVideo(youtubetest.Video{ID: fmt.Sprintf("gopher%05d", i), Title: "Gopher", Duration: time.Minute})
+	}
+	client := &youtube.Client{BaseURL: fake.URL}
+
+	results, err := searchAll(context.Background(), client, "gopher", nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 || fake.Requests("search") != 2 {
+		t.Errorf("got %d results with %d requests", len(results), fake.Requests("search"))
+	}
+
+	results, err = searchAll(context.Background(), client, "gopher", nil, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 5 {
+		t.Errorf("got %d results instead of all 5", len(results))
+	}
+
+	// no results is not a limit
+	for _, limit := range []int{0, -1} {
+		if _, err = searchAll(context.Background(), client, "gopher", nil, limit); err == nil {
+			t.Errorf("limit %d is accepted", limit)
+		}
+	}
+
+	var out bytes.Buffer
+	printSearchIDs(&out, append(results[:2:2], youtube.SearchResult{Kind: youtube.ReferenceChannel, ID: "UCgophers"}))
+	if out.String() != "gopher00000\ngopher00001\n" {
+		t.Errorf("unexpected IDs %q", out.String())
+	}
+
+	out.Reset()
+	printSearchResults(&out, results[:1])
+	if !strings.Contains(out.String(), "gopher00000") || !strings.Contains(out.String(), "1m0s") {
+		t.Errorf("unexpected table:\n%s", out.String())
+	}
+}
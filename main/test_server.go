@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// request sends a request to the server and decodes the JSON answer into v
+func request(t *testing.T, s *server, method, path, body string, v interface{}) int {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s %s: invalid JSON %q: %v", method, path, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+// waitForStatus polls the job until it has the status
+func waitForStatus(t *testing.T, s *server, id string, status jobStatus) job {
+	t.Helper()
+
+	var j job
+	for i := 0; i < 100; i++ {
+		request(t, s, http.MethodGet, "/jobs/"+id, "", &j)
+		if j.Status == status {
+			return j
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s is %s instead of %s", id, j.Status, status)
+	return j
+}
+
+func TestServerJobs(t *testing.T) {
+	s := newServer(1, time.Hour)
+	release := make(chan struct{})
+	s.download = func(ctx context.Context, j *job) error {
+		if j.Format == "fail" {
+			return errors.New("no format matches")
+		}
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	defer s.cancelJobs()
+
+	var first, second, third job
+	if code := request(t, s, http.MethodPost, "/jobs", `{"video": "Jl8fV1jUQPs"}`, &first); code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", code)
+	}
+	waitForStatus(t, s, first.ID, jobRunning)
+
+	// the second job waits for the only slot and gets canceled meanwhile
+	request(t, s, http.MethodPost, "/jobs", `{"video": "https://youtu.be/450p7goxZqg"}`, &second)
+	if code := request(t, s, http.MethodDelete, "/jobs/"+second.ID, "", nil); code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", code)
+	}
+	waitForStatus(t, s, second.ID, jobCanceled)
+
+	close(release)
+	done := waitForStatus(t, s, first.ID, jobDone)
+	if done.Finished == nil {
+		t.Error("a finished job needs a finish time")
+	}
+
+	request(t, s, http.MethodPost, "/jobs", `{"video": "Jl8fV1jUQPs", "format": "fail"}`, &third)
+	failed := waitForStatus(t, s, third.ID, jobFailed)
+	if failed.Error != "no format matches" {
+		t.Errorf("unexpected error %q", failed.Error)
+	}
+
+	var jobs []job
+	request(t, s, http.MethodGet, "/jobs", "", &jobs)
+	if len(jobs) != 3 || jobs[0].ID != first.ID || jobs[2].ID != third.ID {
+		t.Errorf("unexpected jobs %+v", jobs)
+	}
+}
+
+func TestServerJobRetention(t *testing.T) {
+	s := newServer(1, time.Minute)
+	s.download = func(ctx context.Context, j *job) error { return nil }
+	defer s.cancelJobs()
+
+	// deleting a finished job removes it
+	var first, second job
+	request(t, s, http.MethodPost, "/jobs", `{"video": "Jl8fV1jUQPs"}`, &first)
+	waitForStatus(t, s, first.ID, jobDone)
+	if code := request(t, s, http.MethodDelete, "/jobs/"+first.ID, "", nil); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := request(t, s, http.MethodGet, "/jobs/"+first.ID, "", nil); code != http.StatusNotFound {
+		t.Errorf("a removed job must be gone, got %d", code)
+	}
+
+	// finished jobs are dropped after the retention time
+	request(t, s, http.MethodPost, "/jobs", `{"video": "Jl8fV1jUQPs"}`, &second)
+	waitForStatus(t, s, second.ID, jobDone)
+	s.pruneJobs(time.Now())
+	if len(s.jobs) != 1 {
+		t.Fatal("a recently finished job must be kept")
+	}
+	s.pruneJobs(time.Now().Add(2 * time.Minute))
+	if len(s.jobs) != 0 {
+		t.Errorf("%d jobs were kept after the retention time", len(s.jobs))
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	s := newServer(1, time.Hour)
+
+	tests := []struct {
+		method, path, body string
+		code               int
+	}{
+		{http.MethodPost, "/jobs", `{"video": `, http.StatusBadRequest},
+		{http.MethodPost, "/jobs", `{"video": "short"}`, http.StatusBadRequest},
+		{http.MethodGet, "/jobs/42", "", http.StatusNotFound},
+		{http.MethodDelete, "/jobs/42", "", http.StatusNotFound},
+		{http.MethodPut, "/jobs", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/videos/Jl8fV1jUQPs", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/videos/Jl8fV1jUQPs/comments", "", http.StatusNotFound},
+		{http.MethodGet, "/", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		var answer map[string]string
+		if code := request(t, s, tt.method, tt.path, tt.body, &answer); code != tt.code {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.path, tt.code, code)
+		}
+		if answer["error"] == "" {
+			t.Errorf("%s %s: error missing", tt.method, tt.path)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yigitcilce/youtube"
+)
+
+func TestSidecars(t *testing.T) {
+	dir := t.TempDir()
+	videoPath := filepath.Join(dir, "Title [BaW_jenozKc].mp4")
+
+	video := &youtube.Video{
+		ID:          "BaW_jenozKc",
+		Title:       "Title & more",
+		Description: "Plot",
+		Author:      "Channel",
+		Duration:    10*time.Minute + 40*time.Second,
+		PublishDate: time.Date(2012, 10, 2, 0, 0, 0, 0, time.UTC),
+		Thumbnails:  youtube.Thumbnails{{URL: "https://i.ytimg.com/vi/BaW_jenozKc/maxresdefault.jpg", Width: 1280, Height: 720}},
+	}
+	format := &youtube.Format{ItagNo: 18, MimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`}
+
+	if err := writeInfoJSON(videoPath, video, format); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeNFO(videoPath, video); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "Title [BaW_jenozKc].info.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var info infoFile
+	if err = json.Unmarshal(data, &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Video.Title != video.Title || info.Video.Duration != video.Duration || info.Format.ItagNo != 18 {
+		t.Errorf("unexpected info %+v %+v", info.Video, info.Format)
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, "Title [BaW_jenozKc].nfo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nfo := string(data)
+	for _, want := range []string{
+		"<title>Title &amp; more</title>",
+		"<runtime>11</runtime>",
+		"<premiered>2012-10-02</premiered>",
+		`<uniqueid type="youtube" default="true">BaW_jenozKc</uniqueid>`,
+		`<thumb aspect="thumb">https://i.ytimg.com/vi/BaW_jenozKc/maxresdefault.jpg</thumb>`,
+	} {
+		if !strings.Contains(nfo, want) {
+			t.Errorf("%s missing in %s", want, nfo)
+		}
+	}
+
+	// no temporary files are left behind
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("expected 2 files, got %d", len(entries))
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/yigitcilce/youtube"
+)
+
+func TestParseProxyRotation(t *testing.T) {
+	for value, want := range map[string]youtube.ProxyRotation{"429": youtube.RotateOnRateLimit, "video": youtube.RotatePerVideo} {
+		if rotation, err := parseProxyRotation(value); err != nil || rotation != want {
+			t.Errorf("%q: got %q, %v", value, rotation, err)
+		}
+	}
+
+	for _, value := range []string{"", "Video", "503", "request"} {
+		if _, err := parseProxyRotation(value); err == nil {
+			t.Errorf("%q must be rejected", value)
+		}
+	}
+}
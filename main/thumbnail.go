@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/yigitcilce/youtube"
+	"github.com/yigitcilce/youtube/audio"
+)
+
+// videoTags are the tags written into audio files and, with a cover, into MP4 files
+func videoTags(v *youtube.Video) audio.Tags {
+	return audio.Tags{
+		Title:  v.Title,
+		Artist: v.Author,
+		// channels are the closest thing to an album, e.g. for podcasts
+		Album: v.Author,
+	}
+}
+
+// thumbnail fetches the largest thumbnail, a missing thumbnail doesn't fail the download
+func (yt *Downloader) thumbnail(ctx context.Context, v *youtube.Video) []byte {
+	image, size, err := yt.GetThumbnail(ctx, v, youtube.ThumbnailMaxRes)
+	if err != nil {
+		yt.logf("Thumbnail of '%s' not available: %v", v.Title, err)
+		return nil
+	}
+
+	yt.logf("Thumbnail '%s'", size)
+	return image
+}
+
+// writeThumbnail saves the image next to the video, with the same name
+func writeThumbnail(videoPath string, image []byte) error {
+	return writeFileAtomic(sidecarPath(videoPath, ".jpg"), image)
+}
+
+// embedTags writes the tags with the cover into the downloaded MP4 file, which must be closed.
+// The file is rewritten into a temporary file, which replaces it at the end.
+func (yt *Downloader) embedTags(path, ext string, tags audio.Tags) error {
+	if ext != "mp4" && ext != "m4a" {
+		yt.logf("The thumbnail can only be embedded into MP4 and M4A files, not into .%s", ext)
+		return nil
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cover-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err = audio.EmbedTags(tmp, in, tags); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	in.Close()
+	return os.Rename(tmp.Name(), path)
+}
@@ -1,53 +1,203 @@
-package main
-
-import (
-	"errors"
-	"net"
-	"net/http"
-	"time"
-
-	"github.com/yigitcilce/youtube"
-)
-
-var downloader *Downloader
-
-func getDownloader() *Downloader {
-	// Once downloader is configured, use it
-	if downloader != nil {
-		return downloader
-	}
-
-	// Initialize Downloader
-	downloader = &Downloader{}
-
-	// Connection rules for downloader
-	httpTransport := &http.Transport{
-		IdleConnTimeout:       60 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
-		ForceAttemptHTTP2:     true,
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
-	}
-
-	// Assign http rules
-	downloader.HTTPClient = &http.Client{Transport: httpTransport}
-
-	return downloader
-}
-
-// getVideoWithFormat gets video and its format for downloading process
-func getVideoWithFormat(id string) (*youtube.Video, *youtube.Format, error) {
-	yt := getDownloader()
-	video, err := yt.GetVideo(id)
-	if err != nil {
-		return nil, nil, err
-	}
-	formats := video.Formats
-
-	if len(formats) == 0 {
-		return nil, nil, errors.New("no formats found")
-	}
-	return video, &formats[0], nil
-}
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/yigitcilce/youtube"
+	"github.com/yigitcilce/youtube/youtubetest"
+)
+
+var downloader *Downloader
+
+func getDownloader() *Downloader {
+	// Once downloader is configured, use it
+	if downloader != nil {
+		return downloader
+	}
+
+	// Initialize Downloader
+	downloader = &Downloader{}
+
+	// Connection rules for downloader
+	httpTransport := &http.Transport{
+		IdleConnTimeout:       60 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		ForceAttemptHTTP2:     true,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+	}
+
+	// Route through proxies from --proxy or the config file
+	if proxies := viper.GetStringSlice("proxy"); len(proxies) > 0 {
+		pool, err := youtube.NewProxyPool(proxies...)
+		exitOnError(err)
+		pool.Rotation, err = parseProxyRotation(viper.GetString("proxy-rotate"))
+		exitOnError(err)
+
+		downloader.Proxies = pool
+		httpTransport = pool.Transport(httpTransport)
+	}
+
+	// Cap the bandwidth with --limit-rate
+	if limit := viper.GetString("limit-rate"); limit != "" {
+		bytesPerSecond, err := parseByteSize(limit)
+		exitOnError(err)
+
+		downloader.RateLimit = youtube.NewRateLimiter(bytesPerSecond)
+	}
+
+	// Record the traffic for debugging with --record, play it back with --replay
+	var transport http.RoundTripper = httpTransport
+	if dir := viper.GetString("record"); dir != "" {
+		recorder, err := youtubetest.NewRecorder(dir, transport)
+		exitOnError(err)
+		transport = recorder
+	}
+	if dir := viper.GetString("replay"); dir != "" {
+		replayer, err := youtubetest.NewReplayer(dir)
+		exitOnError(err)
+		transport = replayer
+	}
+
+	// Assign http rules
+	downloader.HTTPClient = &http.Client{Transport: transport}
+
+	return downloader
+}
+
+// formatOptions chooses the format to download
+type formatOptions struct {
+	itag      int
+	quality   string
+	mime      string
+	audioOnly bool
+	videoOnly bool
+	selector  string
+}
+
+// getVideoWithFormat gets video and its format for downloading process
+func getVideoWithFormat(ctx context.Context, id string, opts formatOptions) (*youtube.Video, *youtube.Format, error) {
+	yt := getDownloader()
+	video, err := yt.GetVideoContext(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	formats := video.Formats
+
+	if len(formats) == 0 {
+		return nil, nil, errors.New("no formats found")
+	}
+
+	format, err := selectFormat(formats, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return video, format, nil
+}
+
+// selectFormat applies the format flags, the best of the matching formats wins.
+// Without any flag the first format is used.
+func selectFormat(formats youtube.FormatList, opts formatOptions) (*youtube.Format, error) {
+	if opts.selector != "" {
+		format, err := formats.Select(opts.selector)
+		if err != nil {
+			return nil, withAvailableFormats(err, formats)
+		}
+		return format, nil
+	}
+
+	if opts == (formatOptions{}) {
+		return &formats[0], nil
+	}
+
+	matching := formats
+	if opts.itag != 0 {
+		matching = matching.Itag(opts.itag)
+	}
+	if opts.quality != "" {
+		matching = matching.Quality(opts.quality)
+	}
+	if opts.mime != "" {
+		matching = matching.Type(opts.mime)
+	}
+	if opts.audioOnly {
+		matching = matching.AudioOnly()
+	}
+	if opts.videoOnly {
+		matching = matching.VideoOnly()
+	}
+
+	if len(matching) == 0 {
+		return nil, withAvailableFormats(errors.New("no format matches the given flags"), formats)
+	}
+	return matching.Best(), nil
+}
+
+// withAvailableFormats adds the list of formats to err, so the user can pick one
+func withAvailableFormats(err error, formats youtube.FormatList) error {
+	return &formatNotFoundError{err: err, formats: formats}
+}
+
+// formatNotFoundError lists the available formats below its cause
+type formatNotFoundError struct {
+	err     error
+	formats youtube.FormatList
+}
+
+func (e *formatNotFoundError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v\navailable formats:", e.err)
+	for _, f := range e.formats {
+		quality := f.QualityLabel
+		if quality == "" {
+			quality = f.AudioQuality
+		}
+		fmt.Fprintf(&b, "\n  itag %-4d %-22s %-10s %s", f.ItagNo, quality, formatSize(f.ContentLength), f.MimeType)
+	}
+	return b.String()
+}
+
+func (e *formatNotFoundError) Unwrap() error {
+	return e.err
+}
+
+// parseProxyRotation checks the value of --proxy-rotate
+func parseProxyRotation(value string) (youtube.ProxyRotation, error) {
+	switch rotation := youtube.ProxyRotation(value); rotation {
+	case youtube.RotateOnRateLimit, youtube.RotatePerVideo:
+		return rotation, nil
+	}
+	return "", fmt.Errorf("invalid proxy rotation %q, use 429 or video", value)
+}
+
+// parseByteSize parses sizes like 500K, 2M or 1.5G into bytes
+func parseByteSize(size string) (int64, error) {
+	units := map[byte]float64{'K': 1 << 10, 'M': 1 << 20, 'G': 1 << 30}
+
+	number := strings.ToUpper(strings.TrimSpace(size))
+	multiplier := 1.0
+	if number != "" {
+		if unit, ok := units[number[len(number)-1]]; ok {
+			multiplier = unit
+			number = number[:len(number)-1]
+		}
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q, use a number with an optional K, M or G suffix", size)
+	}
+
+	return int64(value * multiplier), nil
+}
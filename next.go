@@ -0,0 +1,285 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WatchNext is what the watch page shows next to the player, fetched with GetWatchNext
+// or by GetVideo when Client.WatchNext is set
+type WatchNext struct {
+	Likes       int64
+	Subscribers int64
+	Related     []CompactVideo
+	Chapters    []Chapter
+	// Playlist is set when the video was opened in a playlist, like watch?v=...&list=...
+	Playlist *WatchPlaylist
+}
+
+// CompactVideo is a video of the related videos or of a playlist
+type CompactVideo struct {
+	ID        string
+	Title     string
+	Author    string
+	ChannelID string
+	// Duration is 0 for live streams
+	Duration time.Duration
+	Views    int64
+	// Published is the relative upload time, like "3 days ago"
+	Published string
+	Live      bool
+}
+
+// Chapter is a chapter of the video
+type Chapter struct {
+	Title string
+	Start time.Duration
+}
+
+// WatchPlaylist is the playlist a video is watched in
+type WatchPlaylist struct {
+	ID     string
+	Title  string
+	Author string
+	// Index is the position of the video in the playlist, starting at 1
+	Index int
+	Total int
+	// Videos are the videos around the current one, YouTube sends a window of the playlist
+	Videos []CompactVideo
+}
+
+type innertubeNextRequest struct {
+	Context       inntertubeContext `json:"context"`
+	VideoID       string            `json:"videoId,omitempty"`
+	PlaylistID    string            `json:"playlistId,omitempty"`
+	PlaylistIndex int               `json:"playlistIndex,omitempty"`
+	Continuation  string            `json:"continuation,omitempty"`
+}
+
+// GetWatchNext fetches likes, subscribers, related videos, chapters and the playlist of a video.
+// The playlist is taken from the list parameter of the URL.
+func (c *Client) GetWatchNext(ctx context.Context, url string) (*WatchNext, error) {
+	ref, err := ParseURL(url)
+	if err == nil && ref.VideoID == "" {
+		err = fmt.Errorf("%w: a %s has no video id", ErrUnsupportedURL, ref.Kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("extractVideoID failed: %w", err)
+	}
+
+	body, err := c.next(ctx, innertubeNextRequest{VideoID: ref.VideoID, PlaylistID: ref.PlaylistID, PlaylistIndex: ref.Index})
+	if err != nil {
+		return nil, err
+	}
+
+	var data watchNextResponseData
+	if err = json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("unable to parse next response JSON: %w", err)
+	}
+	return data.watchNext(), nil
+}
+
+// next calls the next endpoint of the innertube API, which answers for the watch page of a video
+// with everything but the streams, and for continuations of it like comments
+func (c *Client) next(ctx context.Context, data innertubeNextRequest) ([]byte, error) {
+	innertubeCtx, key := prepareInnertubeContext(Web)
+	data.Context = innertubeCtx
+	return c.innertubePost(ctx, "next", key, data)
+}
+
+// watchNextResponseData presents the parts of the answer for a video
+type watchNextResponseData struct {
+	Contents struct {
+		TwoColumnWatchNextResults struct {
+			Results struct {
+				Results struct {
+					Contents []watchNextContent `json:"contents"`
+				} `json:"results"`
+			} `json:"results"`
+			SecondaryResults struct {
+				SecondaryResults struct {
+					Results []compactItem `json:"results"`
+				} `json:"secondaryResults"`
+			} `json:"secondaryResults"`
+			Playlist struct {
+				Playlist *struct {
+					PlaylistID   string        `json:"playlistId"`
+					Title        string        `json:"title"`
+					OwnerName    innertubeText `json:"ownerName"`
+					CurrentIndex int           `json:"currentIndex"`
+					TotalVideos  int           `json:"totalVideos"`
+					Contents     []compactItem `json:"contents"`
+				} `json:"playlist"`
+			} `json:"playlist"`
+		} `json:"twoColumnWatchNextResults"`
+	} `json:"contents"`
+	PlayerOverlays struct {
+		PlayerOverlayRenderer struct {
+			DecoratedPlayerBarRenderer struct {
+				DecoratedPlayerBarRenderer struct {
+					PlayerBar struct {
+						MultiMarkersPlayerBarRenderer struct {
+							MarkersMap []struct {
+								Key   string `json:"key"`
+								Value struct {
+									Chapters []struct {
+										ChapterRenderer struct {
+											Title                innertubeText `json:"title"`
+											TimeRangeStartMillis int64         `json:"timeRangeStartMillis"`
+										} `json:"chapterRenderer"`
+									} `json:"chapters"`
+								} `json:"value"`
+							} `json:"markersMap"`
+						} `json:"multiMarkersPlayerBarRenderer"`
+					} `json:"playerBar"`
+				} `json:"decoratedPlayerBarRenderer"`
+			} `json:"decoratedPlayerBarRenderer"`
+		} `json:"playerOverlayRenderer"`
+	} `json:"playerOverlays"`
+}
+
+type watchNextContent struct {
+	VideoPrimaryInfoRenderer *struct {
+		VideoActions struct {
+			MenuRenderer struct {
+				TopLevelButtons []struct {
+					ToggleButtonRenderer               *toggleButtonRenderer `json:"toggleButtonRenderer"`
+					SegmentedLikeDislikeButtonRenderer *struct {
+						LikeButton struct {
+							ToggleButtonRenderer toggleButtonRenderer `json:"toggleButtonRenderer"`
+						} `json:"likeButton"`
+					} `json:"segmentedLikeDislikeButtonRenderer"`
+				} `json:"topLevelButtons"`
+			} `json:"menuRenderer"`
+		} `json:"videoActions"`
+	} `json:"videoPrimaryInfoRenderer"`
+	VideoSecondaryInfoRenderer *struct {
+		Owner struct {
+			VideoOwnerRenderer struct {
+				SubscriberCountText innertubeText `json:"subscriberCountText"`
+			} `json:"videoOwnerRenderer"`
+		} `json:"owner"`
+	} `json:"videoSecondaryInfoRenderer"`
+	ItemSectionRenderer *struct {
+		SectionIdentifier string                     `json:"sectionIdentifier"`
+		Contents          []continuationItemsContent `json:"contents"`
+	} `json:"itemSectionRenderer"`
+}
+
+type toggleButtonRenderer struct {
+	DefaultIcon struct {
+		IconType string `json:"iconType"`
+	} `json:"defaultIcon"`
+	DefaultText struct {
+		Accessibility struct {
+			AccessibilityData struct {
+				Label string `json:"label"`
+			} `json:"accessibilityData"`
+		} `json:"accessibility"`
+	} `json:"defaultText"`
+}
+
+type continuationItemsContent struct {
+	ContinuationItemRenderer *continuationItemRenderer `json:"continuationItemRenderer"`
+}
+
+// compactItem is an entry of the related videos or of the playlist panel
+type compactItem struct {
+	CompactVideoRenderer       *compactVideoRenderer `json:"compactVideoRenderer"`
+	PlaylistPanelVideoRenderer *compactVideoRenderer `json:"playlistPanelVideoRenderer"`
+}
+
+type compactVideoRenderer struct {
+	VideoID           string        `json:"videoId"`
+	Title             innertubeText `json:"title"`
+	LongBylineText    innertubeText `json:"longBylineText"`
+	LengthText        innertubeText `json:"lengthText"`
+	ViewCountText     innertubeText `json:"viewCountText"`
+	PublishedTimeText innertubeText `json:"publishedTimeText"`
+	Badges            []struct {
+		MetadataBadgeRenderer struct {
+			Style string `json:"style"`
+		} `json:"metadataBadgeRenderer"`
+	} `json:"badges"`
+}
+
+func (r *compactVideoRenderer) video() CompactVideo {
+	video := CompactVideo{
+		ID:        r.VideoID,
+		Title:     r.Title.String(),
+		Author:    r.LongBylineText.String(),
+		ChannelID: r.LongBylineText.browseID(),
+		Duration:  parseClock(r.LengthText.String()),
+		Views:     parseCount(r.ViewCountText.String()),
+		Published: r.PublishedTimeText.String(),
+	}
+	for _, badge := range r.Badges {
+		if badge.MetadataBadgeRenderer.Style == "BADGE_STYLE_TYPE_LIVE_NOW" {
+			video.Live = true
+		}
+	}
+	return video
+}
+
+func (data *watchNextResponseData) watchNext() *WatchNext {
+	results := &data.Contents.TwoColumnWatchNextResults
+	next := &WatchNext{}
+
+	for _, content := range results.Results.Results.Contents {
+		if info := content.VideoPrimaryInfoRenderer; info != nil {
+			// the label is like "1,234 likes", older pages have a toggle button per action
+			for _, button := range info.VideoActions.MenuRenderer.TopLevelButtons {
+				if segmented := button.SegmentedLikeDislikeButtonRenderer; segmented != nil {
+					next.Likes = parseCount(segmented.LikeButton.ToggleButtonRenderer.DefaultText.Accessibility.AccessibilityData.Label)
+				} else if toggle := button.ToggleButtonRenderer; toggle != nil && toggle.DefaultIcon.IconType == "LIKE" {
+					next.Likes = parseCount(toggle.DefaultText.Accessibility.AccessibilityData.Label)
+				}
+			}
+		}
+		if info := content.VideoSecondaryInfoRenderer; info != nil {
+			// like "1.23M subscribers"
+			subscribers := strings.Fields(info.Owner.VideoOwnerRenderer.SubscriberCountText.String())
+			if len(subscribers) > 0 {
+				next.Subscribers = parseShortCount(subscribers[0])
+			}
+		}
+	}
+
+	for _, item := range results.SecondaryResults.SecondaryResults.Results {
+		if item.CompactVideoRenderer != nil {
+			next.Related = append(next.Related, item.CompactVideoRenderer.video())
+		}
+	}
+
+	for _, marker := range data.PlayerOverlays.PlayerOverlayRenderer.DecoratedPlayerBarRenderer.DecoratedPlayerBarRenderer.PlayerBar.MultiMarkersPlayerBarRenderer.MarkersMap {
+		if len(marker.Value.Chapters) == 0 || len(next.Chapters) > 0 {
+			continue
+		}
+		for _, chapter := range marker.Value.Chapters {
+			next.Chapters = append(next.Chapters, Chapter{
+				Title: chapter.ChapterRenderer.Title.String(),
+				Start: time.Duration(chapter.ChapterRenderer.TimeRangeStartMillis) * time.Millisecond,
+			})
+		}
+	}
+
+	if playlist := results.Playlist.Playlist; playlist != nil {
+		next.Playlist = &WatchPlaylist{
+			ID:     playlist.PlaylistID,
+			Title:  playlist.Title,
+			Author: playlist.OwnerName.String(),
+			Index:  playlist.CurrentIndex + 1,
+			Total:  playlist.TotalVideos,
+		}
+		for _, item := range playlist.Contents {
+			if item.PlaylistPanelVideoRenderer != nil {
+				next.Playlist.Videos = append(next.Playlist.Videos, item.PlaylistPanelVideoRenderer.video())
+			}
+		}
+	}
+
+	return next
+}
@@ -1,64 +1,77 @@
-package youtube
-
-import (
-	"context"
-	"errors"
-	"fmt"
-	"regexp"
-	"time"
-)
-
-type playerConfig []byte
-type playerCache struct {
-	key       string
-	expiredAt time.Time
-	config    playerConfig
-}
-
-var basejsPattern = regexp.MustCompile(`(/s/player/\w+/player_ias.vflset/\w+/base.js)`)
-var signatureRegexp = regexp.MustCompile(`(?m)(?:^|,)(?:signatureTimestamp:)(\d+)`)
-
-const defaultCacheExpiration = time.Minute * time.Duration(5)
-
-// Get : get cache when it has same video id and not expired
-func (s playerCache) Get(key string) playerConfig {
-	if key == s.key && s.expiredAt.After(time.Now()) {
-		return s.config
-	}
-	return nil
-}
-
-// Set : set cache with default expiration
-func (s *playerCache) Set(key string, operations playerConfig) {
-	s.key = key
-	s.config = operations
-	s.expiredAt = time.Now().Add(defaultCacheExpiration)
-}
-
-func (c *Client) getPlayerConfig(ctx context.Context, videoID string) (playerConfig, error) {
-	// Flag 3: Embed Video
-	embedURL := fmt.Sprintf("https://youtube.com/embed/%s?hl=en", videoID)
-	embedBody, err := c.httpGetBodyBytes(ctx, embedURL)
-	if err != nil {
-		return nil, err
-	}
-
-	escapedBasejsURL := string(basejsPattern.Find(embedBody))
-	if escapedBasejsURL == "" {
-		return nil, errors.New("unable to find basejs URL in playerConfig")
-	}
-
-	config := c.playerCache.Get(escapedBasejsURL)
-	if config != nil {
-		return config, nil
-	}
-
-	// Flag 4: JS source
-	config, err = c.httpGetBodyBytes(ctx, "https://youtube.com"+escapedBasejsURL)
-	if err != nil {
-		return nil, err
-	}
-
-	c.playerCache.Set(escapedBasejsURL, config)
-	return config, nil
-}
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+)
+
+type playerConfig []byte
+type playerCache struct {
+	// mu guards the cache, a Client is shared by concurrent downloads
+	mu        sync.Mutex
+	key       string
+	expiredAt time.Time
+	config    playerConfig
+	// functions are the ones extracted from config, they are dropped with it
+	functions *extractedFunctions
+}
+
+var basejsPattern = regexp.MustCompile(`(/s/player/\w+/player_ias.vflset/\w+/base.js)`)
+var signatureRegexp = regexp.MustCompile(`(?m)(?:^|,)(?:signatureTimestamp:)(\d+)`)
+
+const defaultCacheExpiration = time.Minute * time.Duration(5)
+
+// Get : get cache and the functions extracted from it when it has same video id and not expired
+func (s *playerCache) Get(key string) (playerConfig, *extractedFunctions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key == s.key && s.expiredAt.After(time.Now()) {
+		return s.config, s.functions
+	}
+	return nil, nil
+}
+
+// Set : set cache with default expiration, it returns the functions of the new player which are still to be extracted
+func (s *playerCache) Set(key string, operations playerConfig) *extractedFunctions {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.key = key
+	s.config = operations
+	s.functions = &extractedFunctions{}
+	s.expiredAt = time.Now().Add(defaultCacheExpiration)
+	return s.functions
+}
+
+// getPlayerConfig returns the player of the video with the cache of the functions extracted from it
+func (c *Client) getPlayerConfig(ctx context.Context, videoID string) (playerConfig, *extractedFunctions, error) {
+	// Flag 3: Embed Video
+	embedURL := fmt.Sprintf("%s/embed/%s?hl=en", c.baseURL(), videoID)
+	embedBody, err := c.httpGetBodyBytes(ctx, embedURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	escapedBasejsURL := string(basejsPattern.Find(embedBody))
+	if escapedBasejsURL == "" {
+		return nil, nil, errors.New("unable to find basejs URL in playerConfig")
+	}
+
+	config, functions := c.playerCache.Get(escapedBasejsURL)
+	if config != nil {
+		return config, functions, nil
+	}
+
+	// Flag 4: JS source
+	config, err = c.httpGetBodyBytes(ctx, c.baseURL()+escapedBasejsURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return config, c.playerCache.Set(escapedBasejsURL, config), nil
+}
@@ -0,0 +1,169 @@
+package youtube
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	progressInterval = time.Millisecond * time.Duration(500)
+	// weight of the latest speed in the average speed
+	progressSmoothing = 0.3
+)
+
+// Progress describes the state of a running download
+type Progress struct {
+	VideoID string
+	ItagNo  int
+
+	Downloaded int64
+	// Total is 0 if the size is unknown
+	Total int64
+
+	// Speed is the speed since the last report in bytes per second
+	Speed float64
+	// AverageSpeed is the exponentially weighted moving average of Speed
+	AverageSpeed float64
+	// ETA is estimated from AverageSpeed, 0 if unknown
+	ETA time.Duration
+
+	// Chunk is the index of the chunk downloaded most recently, out of Chunks
+	Chunk  int
+	Chunks int
+
+	// Done is set in the last report, Err tells whether the download failed
+	Done bool
+	Err  error
+}
+
+// ProgressFunc receives the progress of downloads, it must not block
+type ProgressFunc func(Progress)
+
+// progressTracker collects the progress of a single download and reports it periodically
+type progressTracker struct {
+	mu         sync.Mutex
+	report     ProgressFunc
+	progress   Progress
+	lastReport time.Time
+	lastBytes  int64
+
+	// now is the clock, it's replaced in tests
+	now func() time.Time
+}
+
+// newProgressTracker returns nil when the client has no OnProgress callback
+func (c *Client) newProgressTracker(video *Video, format *Format, total int64) *progressTracker {
+	if c.OnProgress == nil {
+		return nil
+	}
+
+	chunks := 1
+	if total > 0 {
+		chunks = int((total + chunkSize - 1) / chunkSize)
+	}
+
+	return &progressTracker{
+		report: c.OnProgress,
+		progress: Progress{
+			VideoID: video.ID,
+			ItagNo:  format.ItagNo,
+			Total:   total,
+			Chunks:  chunks,
+		},
+		lastReport: time.Now(),
+		now:        time.Now,
+	}
+}
+
+// startChunk marks the chunk at the given offset as the current one
+func (t *progressTracker) startChunk(offset int64) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	t.progress.Chunk = int(offset / chunkSize)
+	t.mu.Unlock()
+}
+
+// add counts n downloaded bytes and reports if the interval passed
+func (t *progressTracker) add(n int) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	t.progress.Downloaded += int64(n)
+
+	now := t.now()
+	if now.Sub(t.lastReport) < progressInterval {
+		t.mu.Unlock()
+		return
+	}
+	t.update(now)
+	progress := t.progress
+	t.mu.Unlock()
+
+	t.report(progress)
+}
+
+// finish sends the last report with the error the download ended with
+func (t *progressTracker) finish(err error) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	t.update(t.now())
+	t.progress.Done = true
+	t.progress.Err = err
+	t.progress.ETA = 0
+	progress := t.progress
+	t.mu.Unlock()
+
+	t.report(progress)
+}
+
+// update calculates the speeds and the ETA
+func (t *progressTracker) update(now time.Time) {
+	elapsed := now.Sub(t.lastReport).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+
+	p := &t.progress
+	p.Speed = float64(p.Downloaded-t.lastBytes) / elapsed
+	if p.AverageSpeed == 0 {
+		p.AverageSpeed = p.Speed
+	} else {
+		p.AverageSpeed += progressSmoothing * (p.Speed - p.AverageSpeed)
+	}
+
+	p.ETA = 0
+	if p.Total > 0 && p.AverageSpeed > 0 {
+		p.ETA = time.Duration(float64(p.Total-p.Downloaded) / p.AverageSpeed * float64(time.Second))
+	}
+
+	t.lastReport = now
+	t.lastBytes = p.Downloaded
+}
+
+// reader counts everything read from r
+func (t *progressTracker) reader(r io.Reader) io.Reader {
+	if t == nil {
+		return r
+	}
+	return &progressReader{r: r, tracker: t}
+}
+
+type progressReader struct {
+	r       io.Reader
+	tracker *progressTracker
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.tracker.add(n)
+	return n, err
+}
@@ -0,0 +1,210 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ProxyRotation decides when a ProxyPool switches to its next proxy
+type ProxyRotation string
+
+const (
+	// RotateOnRateLimit keeps using the same proxy until YouTube answers with 429
+	RotateOnRateLimit ProxyRotation = "429"
+	// RotatePerVideo switches to the next proxy for every fetched video
+	RotatePerVideo ProxyRotation = "video"
+)
+
+const (
+	defaultProxyMaxFailures = 3
+	defaultProxyCooldown    = time.Minute * time.Duration(5)
+)
+
+// ProxyStatus describes the health of a single proxy of a ProxyPool
+type ProxyStatus struct {
+	URL           *url.URL
+	Successes     int
+	Failures      int
+	LastError     error
+	DisabledUntil time.Time
+}
+
+// Healthy reports whether the proxy is currently used for requests
+func (s ProxyStatus) Healthy() bool {
+	return !s.DisabledUntil.After(time.Now())
+}
+
+// ProxyPool routes requests through HTTP(S) or SOCKS5 proxies and rotates between them.
+// Proxies failing MaxFailures times in a row are skipped for Cooldown.
+type ProxyPool struct {
+	Rotation    ProxyRotation
+	MaxFailures int
+	Cooldown    time.Duration
+
+	mu         sync.Mutex
+	proxies    []*proxyEntry
+	current    int
+	httpClient *http.Client
+}
+
+type proxyEntry struct {
+	status              ProxyStatus
+	consecutiveFailures int
+}
+
+type proxyContextKey struct{}
+
+// NewProxyPool creates a pool of the given proxy URLs.
+// Supported schemes are http, https, socks5 and socks5h.
+func NewProxyPool(rawURLs ...string) (*ProxyPool, error) {
+	if len(rawURLs) == 0 {
+		return nil, ErrNoProxies
+	}
+
+	pool := &ProxyPool{
+		Rotation:    RotateOnRateLimit,
+		MaxFailures: defaultProxyMaxFailures,
+		Cooldown:    defaultProxyCooldown,
+	}
+
+	for _, raw := range rawURLs {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %q: %w", raw, err)
+		}
+
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("invalid proxy %q: %w", raw, ErrUnsupportedProxyScheme)
+		}
+
+		pool.proxies = append(pool.proxies, &proxyEntry{status: ProxyStatus{URL: u}})
+	}
+
+	return pool, nil
+}
+
+// Proxy returns the proxy for the given request, it can be used as http.Transport.Proxy
+func (p *ProxyPool) Proxy(req *http.Request) (*url.URL, error) {
+	if entry, ok := req.Context().Value(proxyContextKey{}).(*proxyEntry); ok {
+		return entry.status.URL, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.proxies[p.current].status.URL, nil
+}
+
+// Transport returns a copy of base which sends its requests through the pool
+func (p *ProxyPool) Transport(base *http.Transport) *http.Transport {
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+
+	transport := base.Clone()
+	transport.Proxy = p.Proxy
+	return transport
+}
+
+// client returns the HTTP client used when Client.HTTPClient is not set
+func (p *ProxyPool) client() *http.Client {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.httpClient == nil {
+		p.httpClient = &http.Client{Transport: p.Transport(nil)}
+	}
+	return p.httpClient
+}
+
+// Rotate switches to the next healthy proxy
+func (p *ProxyPool) Rotate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.rotate()
+}
+
+// Status returns a snapshot of the health of all proxies
+func (p *ProxyPool) Status() []ProxyStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	result := make([]ProxyStatus, len(p.proxies))
+	for i, entry := range p.proxies {
+		result[i] = entry.status
+	}
+	return result
+}
+
+// Len returns the number of proxies in the pool
+func (p *ProxyPool) Len() int {
+	return len(p.proxies)
+}
+
+// rotate moves to the next healthy proxy, if every proxy is disabled the next one is used anyway
+func (p *ProxyPool) rotate() {
+	for i := 1; i <= len(p.proxies); i++ {
+		next := (p.current + i) % len(p.proxies)
+		if p.proxies[next].status.Healthy() {
+			p.current = next
+			return
+		}
+	}
+	p.current = (p.current + 1) % len(p.proxies)
+}
+
+// pick returns the proxy that should be used for the next request
+func (p *ProxyPool) pick() *proxyEntry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.proxies[p.current].status.Healthy() {
+		p.rotate()
+	}
+	return p.proxies[p.current]
+}
+
+// report records the result of a request sent through the given proxy
+func (p *ProxyPool) report(entry *proxyEntry, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err == nil {
+		entry.status.Successes++
+		entry.consecutiveFailures = 0
+		return
+	}
+
+	entry.status.Failures++
+	entry.status.LastError = err
+	entry.consecutiveFailures++
+
+	maxFailures := p.MaxFailures
+	if maxFailures <= 0 {
+		maxFailures = defaultProxyMaxFailures
+	}
+	if entry.consecutiveFailures >= maxFailures {
+		cooldown := p.Cooldown
+		if cooldown <= 0 {
+			cooldown = defaultProxyCooldown
+		}
+		entry.status.DisabledUntil = time.Now().Add(cooldown)
+		entry.consecutiveFailures = 0
+	}
+
+	if p.proxies[p.current] == entry {
+		p.rotate()
+	}
+}
+
+// withProxy binds a request to the given proxy, so retries can switch to another one
+func withProxy(req *http.Request, entry *proxyEntry) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), proxyContextKey{}, entry))
+}
@@ -0,0 +1,176 @@
+package youtube
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket limiting the bytes per second read from streams.
+// It is safe for concurrent use, readers sharing a RateLimiter share its bandwidth.
+type RateLimiter struct {
+	mu     sync.Mutex
+	limit  int64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a RateLimiter allowing bytesPerSecond, 0 means unlimited
+func NewRateLimiter(bytesPerSecond int64) *RateLimiter {
+	return &RateLimiter{limit: bytesPerSecond, tokens: float64(bytesPerSecond)}
+}
+
+// Limit returns the current limit in bytes per second
+func (l *RateLimiter) Limit() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.limit
+}
+
+// SetLimit changes the limit, running downloads are affected immediately
+func (l *RateLimiter) SetLimit(bytesPerSecond int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(time.Now())
+	l.limit = bytesPerSecond
+	if l.tokens > float64(bytesPerSecond) {
+		l.tokens = float64(bytesPerSecond)
+	}
+}
+
+// WaitN takes n bytes from the bucket and blocks until they are available
+func (l *RateLimiter) WaitN(ctx context.Context, n int) error {
+	l.mu.Lock()
+	if l.limit <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+
+	// take the tokens in advance, the bucket is refilled while we sleep
+	l.refill(time.Now())
+	l.tokens -= float64(n)
+	delay := time.Duration(-l.tokens / float64(l.limit) * float64(time.Second))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// burst returns the biggest amount of bytes a single read should take
+func (l *RateLimiter) burst() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return int(l.limit)
+}
+
+// refill adds the tokens earned since the last call, the bucket holds one second at most
+func (l *RateLimiter) refill(now time.Time) {
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * float64(l.limit)
+	}
+	if l.tokens > float64(l.limit) {
+		l.tokens = float64(l.limit)
+	}
+	l.last = now
+}
+
+// downloadBucket is the bucket of a single download, it follows the DownloadRateLimit of the client.
+// The bucket is created once a limit is set, even while the download is running.
+type downloadBucket struct {
+	client *Client
+
+	mu       sync.Mutex
+	template *RateLimiter
+	bucket   *RateLimiter
+}
+
+// limiter returns the bucket with the current limit of the client, nil without a limit
+func (d *downloadBucket) limiter() *RateLimiter {
+	if d == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if template := d.client.DownloadRateLimit; template != d.template {
+		d.template = template
+		d.bucket = nil
+		if template != nil {
+			d.bucket = NewRateLimiter(template.Limit())
+		}
+	}
+	if d.bucket != nil {
+		if limit := d.template.Limit(); limit != d.bucket.Limit() {
+			d.bucket.SetLimit(limit)
+		}
+	}
+	return d.bucket
+}
+
+// rateLimitedReader slows down reads to the limits of the client
+type rateLimitedReader struct {
+	ctx         context.Context
+	r           io.Reader
+	global      *RateLimiter
+	perDownload *downloadBucket
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	limiters := []*RateLimiter{r.global, r.perDownload.limiter()}
+
+	// don't take more than one second of bandwidth at once
+	for _, l := range limiters {
+		if l == nil {
+			continue
+		}
+		if burst := l.burst(); burst > 0 && len(p) > burst {
+			p = p[:burst]
+		}
+	}
+
+	n, err := r.r.Read(p)
+	for _, l := range limiters {
+		if l == nil {
+			continue
+		}
+		if waitErr := l.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// downloadRateLimiter returns the bucket of a single download, following the DownloadRateLimit of the client
+func (c *Client) downloadRateLimiter() *downloadBucket {
+	return &downloadBucket{client: c}
+}
+
+// limitReader wraps r with the global and the per-download rate limits
+func (c *Client) limitReader(ctx context.Context, r io.Reader, perDownload *downloadBucket) io.Reader {
+	if c.RateLimit == nil && perDownload == nil {
+		return r
+	}
+
+	return &rateLimitedReader{
+		ctx:         ctx,
+		r:           r,
+		global:      c.RateLimit,
+		perDownload: perDownload,
+	}
+}
@@ -0,0 +1,248 @@
+package youtube
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReferenceKind is the kind of page a YouTube URL points to
+type ReferenceKind string
+
+const (
+	ReferenceVideo    ReferenceKind = "video"
+	ReferenceShort    ReferenceKind = "short"
+	ReferenceLive     ReferenceKind = "live"
+	ReferencePlaylist ReferenceKind = "playlist"
+	ReferenceChannel  ReferenceKind = "channel"
+	ReferenceHandle   ReferenceKind = "handle"
+	ReferenceClip     ReferenceKind = "clip"
+)
+
+// Reference is a parsed YouTube URL or video ID
+type Reference struct {
+	Kind ReferenceKind
+
+	// VideoID is set for videos, shorts and live streams
+	VideoID string
+	// PlaylistID is set for playlists and videos opened in a playlist
+	PlaylistID string
+	// Index is the position of the video in the playlist, starting at 1
+	Index int
+	// Start is the position the video starts at, from t= or start=
+	Start time.Duration
+
+	// ChannelID is the UC... ID of /channel/ URLs
+	ChannelID string
+	// ChannelName is the name of /c/ and /user/ URLs
+	ChannelName string
+	// Handle is the name of /@handle URLs, without the @
+	Handle string
+	// ClipID is the ID of /clip/ URLs
+	ClipID string
+}
+
+var (
+	videoIDRegexp   = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	idRegexp        = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	handleRegexp    = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	startTimeRegexp = regexp.MustCompile(`^(?:(\d+)h)?(?:(\d+)m)?(?:(\d+)s?)?$`)
+	videoPathKinds  = map[string]ReferenceKind{
+		"embed":  ReferenceVideo,
+		"v":      ReferenceVideo,
+		"e":      ReferenceVideo,
+		"shorts": ReferenceShort,
+		"live":   ReferenceLive,
+	}
+)
+
+// ParseURL parses a YouTube URL or a video ID. Supported are youtube.com with www., m. and music.,
+// youtube-nocookie.com and youtu.be with watch, shorts, live, embed, v, playlist, channel, c, user,
+// @handle, clip and attribution_link URLs. Video IDs must be 11 characters of A-Z, a-z, 0-9, _ and -.
+func ParseURL(rawURL string) (*Reference, error) {
+	rawURL = strings.TrimSpace(rawURL)
+
+	// without a slash it can only be an ID
+	if !strings.Contains(rawURL, "/") {
+		if err := validateVideoID(rawURL); err != nil {
+			return nil, err
+		}
+		return &Reference{Kind: ReferenceVideo, VideoID: rawURL}, nil
+	}
+
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	uri, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedURL, err)
+	}
+	if uri.Scheme != "http" && uri.Scheme != "https" {
+		return nil, fmt.Errorf("%w: scheme %s", ErrUnsupportedURL, uri.Scheme)
+	}
+
+	host := strings.ToLower(uri.Hostname())
+	for _, prefix := range []string{"www.", "m.", "music."} {
+		host = strings.TrimPrefix(host, prefix)
+	}
+
+	switch host {
+	case "youtu.be":
+		return parseShortLink(uri)
+	case "youtube.com", "youtube-nocookie.com":
+		return parsePage(uri)
+	}
+	return nil, fmt.Errorf("%w: host %s", ErrUnsupportedURL, uri.Host)
+}
+
+// parseShortLink parses youtu.be/{id}
+func parseShortLink(uri *url.URL) (*Reference, error) {
+	id := strings.Trim(uri.Path, "/")
+	if err := validateVideoID(id); err != nil {
+		return nil, err
+	}
+
+	ref := &Reference{Kind: ReferenceVideo, VideoID: id}
+	return ref, ref.parseQuery(uri)
+}
+
+// parsePage parses the paths of youtube.com
+func parsePage(uri *url.URL) (*Reference, error) {
+	segments := strings.Split(strings.Trim(uri.Path, "/"), "/")
+	query := uri.Query()
+
+	ref := &Reference{}
+	switch first := segments[0]; {
+	case first == "watch":
+		ref.Kind, ref.VideoID = ReferenceVideo, query.Get("v")
+		if ref.VideoID == "" && query.Get("list") != "" {
+			ref.Kind = ReferencePlaylist
+		}
+
+	case first == "playlist":
+		ref.Kind = ReferencePlaylist
+
+	case first == "attribution_link":
+		// u is the linked page, relative to the host
+		target, err := url.Parse(query.Get("u"))
+		if err != nil || target.Path == "" || target.Path == uri.Path {
+			return nil, fmt.Errorf("%w: invalid attribution_link", ErrUnsupportedURL)
+		}
+		return parsePage(target)
+
+	case videoPathKinds[first] != "" && len(segments) > 1:
+		ref.Kind, ref.VideoID = videoPathKinds[first], segments[1]
+		if first == "embed" && ref.VideoID == "videoseries" {
+			ref.Kind, ref.VideoID = ReferencePlaylist, ""
+		}
+
+	case first == "channel" && len(segments) > 1:
+		ref.Kind, ref.ChannelID = ReferenceChannel, segments[1]
+		if !idRegexp.MatchString(ref.ChannelID) {
+			return nil, fmt.Errorf("%w: invalid channel id %q", ErrUnsupportedURL, ref.ChannelID)
+		}
+		return ref, nil
+
+	case (first == "c" || first == "user") && len(segments) > 1:
+		ref.Kind, ref.ChannelName = ReferenceChannel, segments[1]
+		return ref, nil
+
+	case strings.HasPrefix(first, "@") && len(first) > 1:
+		ref.Kind, ref.Handle = ReferenceHandle, first[1:]
+		if !handleRegexp.MatchString(ref.Handle) {
+			return nil, fmt.Errorf("%w: invalid handle %q", ErrUnsupportedURL, ref.Handle)
+		}
+		return ref, nil
+
+	case first == "clip" && len(segments) > 1:
+		ref.Kind, ref.ClipID = ReferenceClip, segments[1]
+		if !idRegexp.MatchString(ref.ClipID) {
+			return nil, fmt.Errorf("%w: invalid clip id %q", ErrUnsupportedURL, ref.ClipID)
+		}
+		return ref, nil
+
+	default:
+		return nil, fmt.Errorf("%w: path %s", ErrUnsupportedURL, uri.Path)
+	}
+
+	if ref.Kind != ReferencePlaylist {
+		if err := validateVideoID(ref.VideoID); err != nil {
+			return nil, err
+		}
+	}
+	if err := ref.parseQuery(uri); err != nil {
+		return nil, err
+	}
+	if ref.Kind == ReferencePlaylist && ref.PlaylistID == "" {
+		return nil, fmt.Errorf("%w: playlist without list", ErrUnsupportedURL)
+	}
+	return ref, nil
+}
+
+// parseQuery reads the playlist, index and start time of the query, t= may also be in the fragment
+func (ref *Reference) parseQuery(uri *url.URL) error {
+	query := uri.Query()
+
+	if list := query.Get("list"); list != "" {
+		if !idRegexp.MatchString(list) {
+			return fmt.Errorf("%w: invalid playlist id %q", ErrUnsupportedURL, list)
+		}
+		ref.PlaylistID = list
+	}
+	if index := query.Get("index"); index != "" {
+		n, err := strconv.Atoi(index)
+		if err != nil || n < 1 {
+			return fmt.Errorf("%w: invalid index %q", ErrUnsupportedURL, index)
+		}
+		ref.Index = n
+	}
+
+	start := query.Get("t")
+	if start == "" {
+		start = query.Get("start")
+	}
+	if start == "" {
+		if fragment, err := url.ParseQuery(uri.Fragment); err == nil {
+			start = fragment.Get("t")
+		}
+	}
+	if start != "" {
+		d, err := parseStartTime(start)
+		if err != nil {
+			return err
+		}
+		ref.Start = d
+	}
+	return nil
+}
+
+// parseStartTime parses 90, 90s, 1m30s and 1h2m3s
+func parseStartTime(value string) (time.Duration, error) {
+	match := startTimeRegexp.FindStringSubmatch(value)
+	if match == nil || value == "" {
+		return 0, fmt.Errorf("%w: invalid start time %q", ErrUnsupportedURL, value)
+	}
+
+	var d time.Duration
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		if match[i+1] != "" {
+			n, _ := strconv.Atoi(match[i+1])
+			d += time.Duration(n) * unit
+		}
+	}
+	return d, nil
+}
+
+// validateVideoID checks for exactly 11 characters of A-Z, a-z, 0-9, _ and -
+func validateVideoID(id string) error {
+	if videoIDRegexp.MatchString(id) {
+		return nil
+	}
+	if id != "" && !idRegexp.MatchString(id) {
+		return ErrInvalidCharactersInVideoID
+	}
+	return ErrVideoIDMinLength
+}
@@ -1,22 +1,127 @@
-package youtube
-
-// responseData presents a part of youtubes video API
-type playerResponseData struct {
-	StreamingData struct {
-		Formats         []Format `json:"formats"`
-		AdaptiveFormats []Format `json:"adaptiveFormats"`
-	} `json:"streamingData"`
-	VideoDetails struct {
-		Title string `json:"title"`
-	} `json:"videoDetails"`
-}
-
-type Format struct {
-	ItagNo   int    `json:"itag"`
-	MimeType string `json:"mimeType"`
-	Quality  string `json:"quality"`
-	Cipher   string `json:"signatureCipher"`
-
-	ContentLength int64  `json:"contentLength,string"`
-	QualityLabel  string `json:"qualityLabel"`
-}
+package youtube
+
+import (
+	"mime"
+	"strings"
+)
+
+// responseData presents a part of youtubes video API
+type playerResponseData struct {
+	StreamingData struct {
+		Formats         []Format `json:"formats"`
+		AdaptiveFormats []Format `json:"adaptiveFormats"`
+	} `json:"streamingData"`
+	VideoDetails struct {
+		Title            string `json:"title"`
+		Author           string `json:"author"`
+		ChannelID        string `json:"channelId"`
+		LengthSeconds    string `json:"lengthSeconds"`
+		ViewCount        string `json:"viewCount"`
+		ShortDescription string `json:"shortDescription"`
+		Thumbnail        struct {
+			Thumbnails []Thumbnail `json:"thumbnails"`
+		} `json:"thumbnail"`
+	} `json:"videoDetails"`
+	Microformat struct {
+		PlayerMicroformatRenderer struct {
+			PublishDate string `json:"publishDate"`
+		} `json:"playerMicroformatRenderer"`
+	} `json:"microformat"`
+}
+
+type Format struct {
+	ItagNo   int    `json:"itag"`
+	URL      string `json:"url"`
+	MimeType string `json:"mimeType"`
+	Quality  string `json:"quality"`
+	Cipher   string `json:"signatureCipher"`
+	Bitrate  int    `json:"bitrate"`
+	FPS      int    `json:"fps"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+
+	ContentLength    int64  `json:"contentLength,string"`
+	QualityLabel     string `json:"qualityLabel"`
+	AverageBitrate   int    `json:"averageBitrate"`
+	AudioQuality     string `json:"audioQuality"`
+	AudioSampleRate  string `json:"audioSampleRate"`
+	AudioChannels    int    `json:"audioChannels"`
+	ApproxDurationMs string `json:"approxDurationMs"`
+}
+
+// Container returns the container of the format from its mime type, e.g. mp4 or webm
+func (f *Format) Container() string {
+	mediaType, _, err := mime.ParseMediaType(f.MimeType)
+	if err != nil {
+		return ""
+	}
+
+	if i := strings.IndexByte(mediaType, '/'); i >= 0 {
+		return mediaType[i+1:]
+	}
+	return mediaType
+}
+
+// Codecs returns the codecs listed in the mime type of the format
+func (f *Format) Codecs() []string {
+	_, params, err := mime.ParseMediaType(f.MimeType)
+	if err != nil || params["codecs"] == "" {
+		return nil
+	}
+
+	codecs := strings.Split(params["codecs"], ",")
+	for i := range codecs {
+		codecs[i] = strings.TrimSpace(codecs[i])
+	}
+	return codecs
+}
+
+// HasVideo reports whether the format contains a video stream
+func (f *Format) HasVideo() bool {
+	return strings.HasPrefix(f.MimeType, "video/")
+}
+
+// HasAudio reports whether the format contains an audio stream
+func (f *Format) HasAudio() bool {
+	return strings.HasPrefix(f.MimeType, "audio/") || f.AudioChannels > 0
+}
+
+// IsCiphered reports whether the stream URL has to be deciphered first
+func (f *Format) IsCiphered() bool {
+	return f.Cipher != ""
+}
+
+// innertubeText is a text of the innertube API, either simple or in runs with links
+type innertubeText struct {
+	SimpleText string `json:"simpleText"`
+	Runs       []struct {
+		Text               string `json:"text"`
+		NavigationEndpoint struct {
+			BrowseEndpoint struct {
+				BrowseID string `json:"browseId"`
+			} `json:"browseEndpoint"`
+		} `json:"navigationEndpoint"`
+	} `json:"runs"`
+}
+
+func (t innertubeText) String() string {
+	if t.SimpleText != "" {
+		return t.SimpleText
+	}
+
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+// browseID returns the first channel linked in the runs
+func (t innertubeText) browseID() string {
+	for _, run := range t.Runs {
+		if id := run.NavigationEndpoint.BrowseEndpoint.BrowseID; id != "" {
+			return id
+		}
+	}
+	return ""
+}
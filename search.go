@@ -0,0 +1,410 @@
+package youtube
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SearchType restricts the results to one kind
+type SearchType string
+
+const (
+	SearchTypeAny      SearchType = ""
+	SearchTypeVideo    SearchType = "video"
+	SearchTypeChannel  SearchType = "channel"
+	SearchTypePlaylist SearchType = "playlist"
+)
+
+// SearchDuration restricts videos by their length
+type SearchDuration string
+
+const (
+	SearchDurationAny    SearchDuration = ""
+	SearchDurationShort  SearchDuration = "short"  // under 4 minutes
+	SearchDurationMedium SearchDuration = "medium" // 4 to 20 minutes
+	SearchDurationLong   SearchDuration = "long"   // over 20 minutes
+)
+
+// SearchUploadDate restricts the results to recent uploads
+type SearchUploadDate string
+
+const (
+	SearchUploadAny   SearchUploadDate = ""
+	SearchUploadHour  SearchUploadDate = "hour"
+	SearchUploadToday SearchUploadDate = "today"
+	SearchUploadWeek  SearchUploadDate = "week"
+	SearchUploadMonth SearchUploadDate = "month"
+	SearchUploadYear  SearchUploadDate = "year"
+)
+
+// SearchFeature requires a feature of the videos
+type SearchFeature string
+
+const (
+	SearchFeatureHD              SearchFeature = "hd"
+	SearchFeatureSubtitles       SearchFeature = "cc"
+	SearchFeatureCreativeCommons SearchFeature = "creative-commons"
+	SearchFeature3D              SearchFeature = "3d"
+	SearchFeatureLive            SearchFeature = "live"
+	SearchFeature4K              SearchFeature = "4k"
+	SearchFeature360             SearchFeature = "360"
+	SearchFeatureHDR             SearchFeature = "hdr"
+)
+
+// SearchSort is the order of the results
+type SearchSort string
+
+const (
+	SearchSortRelevance  SearchSort = ""
+	SearchSortRating     SearchSort = "rating"
+	SearchSortUploadDate SearchSort = "date"
+	SearchSortViews      SearchSort = "views"
+)
+
+// Field numbers of the search filter protobuf, which YouTube takes base64 encoded in params
+var (
+	searchTypeValues       = map[SearchType]uint64{SearchTypeVideo: 1, SearchTypeChannel: 2, SearchTypePlaylist: 3}
+	searchDurationValues   = map[SearchDuration]uint64{SearchDurationShort: 1, SearchDurationLong: 2, SearchDurationMedium: 3}
+	searchUploadDateValues = map[SearchUploadDate]uint64{SearchUploadHour: 1, SearchUploadToday: 2, SearchUploadWeek: 3, SearchUploadMonth: 4, SearchUploadYear: 5}
+	searchSortValues       = map[SearchSort]uint64{SearchSortRating: 1, SearchSortUploadDate: 2, SearchSortViews: 3}
+	searchFeatureFields    = map[SearchFeature]uint64{
+		SearchFeatureHD: 4, SearchFeatureSubtitles: 5, SearchFeatureCreativeCommons: 6, SearchFeature3D: 7,
+		SearchFeatureLive: 8, SearchFeature4K: 14, SearchFeature360: 15, SearchFeatureHDR: 25,
+	}
+)
+
+// SearchOptions are the filters of a search, the zero value searches everything by relevance
+type SearchOptions struct {
+	Type       SearchType
+	Duration   SearchDuration
+	UploadDate SearchUploadDate
+	Features   []SearchFeature
+	Sort       SearchSort
+}
+
+// SearchResult is a video, channel or playlist found by Search
+type SearchResult struct {
+	// Kind is ReferenceVideo, ReferenceChannel or ReferencePlaylist
+	Kind ReferenceKind
+	// ID is the ID of the video, channel or playlist
+	ID        string
+	Title     string
+	Author    string
+	ChannelID string
+
+	// Duration is set for videos, except live streams
+	Duration time.Duration
+	// Views is the view count of videos
+	Views int64
+	// Published is the relative upload time of videos, like "3 days ago"
+	Published string
+	// Live is set for running live streams
+	Live bool
+	// VideoCount is set for channels and playlists
+	VideoCount int64
+}
+
+// SearchResults is a page of results
+type SearchResults struct {
+	Results []SearchResult
+	// EstimatedResults is the total number of results, as YouTube guesses it
+	EstimatedResults int64
+	// Continuation fetches the next page with SearchNext, it's empty on the last page
+	Continuation string
+}
+
+type innertubeSearchRequest struct {
+	Context      inntertubeContext `json:"context"`
+	Query        string            `json:"query,omitempty"`
+	Params       string            `json:"params,omitempty"`
+	Continuation string            `json:"continuation,omitempty"`
+}
+
+// Search returns the first page of results for the query, opts may be nil
+func (c *Client) Search(ctx context.Context, query string, opts *SearchOptions) (*SearchResults, error) {
+	if opts == nil {
+		opts = &SearchOptions{}
+	}
+	params, err := opts.params()
+	if err != nil {
+		return nil, err
+	}
+
+	innertubeCtx, key := prepareInnertubeContext(Web)
+	return c.search(ctx, key, innertubeSearchRequest{Context: innertubeCtx, Query: query, Params: params})
+}
+
+// SearchNext returns the page following results
+func (c *Client) SearchNext(ctx context.Context, results *SearchResults) (*SearchResults, error) {
+	if results.Continuation == "" {
+		return nil, ErrNoMoreResults
+	}
+
+	innertubeCtx, key := prepareInnertubeContext(Web)
+	return c.search(ctx, key, innertubeSearchRequest{Context: innertubeCtx, Continuation: results.Continuation})
+}
+
+func (c *Client) search(ctx context.Context, key string, data innertubeSearchRequest) (*SearchResults, error) {
+	body, err := c.innertubePost(ctx, "search", key, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp searchResponseData
+	if err = json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("unable to parse search response JSON: %w", err)
+	}
+	return resp.results(), nil
+}
+
+// params encodes the filters as protobuf like the search page does
+func (opts *SearchOptions) params() (string, error) {
+	var filters []byte
+	if opts.UploadDate != SearchUploadAny {
+		v, ok := searchUploadDateValues[opts.UploadDate]
+		if !ok {
+			return "", fmt.Errorf("unknown upload date %q", opts.UploadDate)
+		}
+		filters = appendProtoVarint(filters, 1, v)
+	}
+	if opts.Type != SearchTypeAny {
+		v, ok := searchTypeValues[opts.Type]
+		if !ok {
+			return "", fmt.Errorf("unknown search type %q", opts.Type)
+		}
+		filters = appendProtoVarint(filters, 2, v)
+	}
+	if opts.Duration != SearchDurationAny {
+		v, ok := searchDurationValues[opts.Duration]
+		if !ok {
+			return "", fmt.Errorf("unknown duration %q", opts.Duration)
+		}
+		filters = appendProtoVarint(filters, 3, v)
+	}
+	for _, feature := range opts.Features {
+		field, ok := searchFeatureFields[feature]
+		if !ok {
+			return "", fmt.Errorf("unknown search feature %q", feature)
+		}
+		filters = appendProtoVarint(filters, field, 1)
+	}
+
+	var params []byte
+	if opts.Sort != SearchSortRelevance {
+		v, ok := searchSortValues[opts.Sort]
+		if !ok {
+			return "", fmt.Errorf("unknown sort order %q", opts.Sort)
+		}
+		params = appendProtoVarint(params, 1, v)
+	}
+	if len(filters) > 0 {
+		params = appendProtoBytes(params, 2, filters)
+	}
+
+	if len(params) == 0 {
+		return "", nil
+	}
+	return url.QueryEscape(base64.StdEncoding.EncodeToString(params)), nil
+}
+
+func appendProtoVarint(b []byte, field, value uint64) []byte {
+	b = appendUvarint(b, field<<3)
+	return appendUvarint(b, value)
+}
+
+func appendProtoBytes(b []byte, field uint64, value []byte) []byte {
+	b = appendUvarint(b, field<<3|2)
+	b = appendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+// searchResponseData presents the parts of the search API answer, of the first page and of continuations
+type searchResponseData struct {
+	EstimatedResults int64 `json:"estimatedResults,string"`
+	Contents         struct {
+		TwoColumnSearchResultsRenderer struct {
+			PrimaryContents struct {
+				SectionListRenderer struct {
+					Contents []searchSection `json:"contents"`
+				} `json:"sectionListRenderer"`
+			} `json:"primaryContents"`
+		} `json:"twoColumnSearchResultsRenderer"`
+	} `json:"contents"`
+	OnResponseReceivedCommands []struct {
+		AppendContinuationItemsAction struct {
+			ContinuationItems []searchSection `json:"continuationItems"`
+		} `json:"appendContinuationItemsAction"`
+	} `json:"onResponseReceivedCommands"`
+}
+
+type searchSection struct {
+	ItemSectionRenderer *struct {
+		Contents []searchItem `json:"contents"`
+	} `json:"itemSectionRenderer"`
+	ContinuationItemRenderer *continuationItemRenderer `json:"continuationItemRenderer"`
+}
+
+type continuationItemRenderer struct {
+	ContinuationEndpoint struct {
+		ContinuationCommand struct {
+			Token string `json:"token"`
+		} `json:"continuationCommand"`
+	} `json:"continuationEndpoint"`
+	// Button is the "Show more replies" button of reply threads
+	Button struct {
+		ButtonRenderer struct {
+			Command struct {
+				ContinuationCommand struct {
+					Token string `json:"token"`
+				} `json:"continuationCommand"`
+			} `json:"command"`
+		} `json:"buttonRenderer"`
+	} `json:"button"`
+}
+
+func (r *continuationItemRenderer) token() string {
+	if token := r.ContinuationEndpoint.ContinuationCommand.Token; token != "" {
+		return token
+	}
+	return r.Button.ButtonRenderer.Command.ContinuationCommand.Token
+}
+
+type searchItem struct {
+	VideoRenderer *struct {
+		VideoID           string        `json:"videoId"`
+		Title             innertubeText `json:"title"`
+		OwnerText         innertubeText `json:"ownerText"`
+		LengthText        innertubeText `json:"lengthText"`
+		ViewCountText     innertubeText `json:"viewCountText"`
+		PublishedTimeText innertubeText `json:"publishedTimeText"`
+		Badges            []struct {
+			MetadataBadgeRenderer struct {
+				Style string `json:"style"`
+			} `json:"metadataBadgeRenderer"`
+		} `json:"badges"`
+	} `json:"videoRenderer"`
+	ChannelRenderer *struct {
+		ChannelID      string        `json:"channelId"`
+		Title          innertubeText `json:"title"`
+		VideoCountText innertubeText `json:"videoCountText"`
+	} `json:"channelRenderer"`
+	PlaylistRenderer *struct {
+		PlaylistID      string        `json:"playlistId"`
+		Title           innertubeText `json:"title"`
+		VideoCount      string        `json:"videoCount"`
+		ShortBylineText innertubeText `json:"shortBylineText"`
+	} `json:"playlistRenderer"`
+}
+
+func (data *searchResponseData) results() *SearchResults {
+	sections := data.Contents.TwoColumnSearchResultsRenderer.PrimaryContents.SectionListRenderer.Contents
+	for _, command := range data.OnResponseReceivedCommands {
+		sections = append(sections, command.AppendContinuationItemsAction.ContinuationItems...)
+	}
+
+	results := &SearchResults{EstimatedResults: data.EstimatedResults}
+	for _, section := range sections {
+		if section.ContinuationItemRenderer != nil {
+			results.Continuation = section.ContinuationItemRenderer.token()
+		}
+		if section.ItemSectionRenderer == nil {
+			continue
+		}
+
+		// ads, shelves and the like are skipped
+		for _, item := range section.ItemSectionRenderer.Contents {
+			if result, ok := item.result(); ok {
+				results.Results = append(results.Results, result)
+			}
+		}
+	}
+	return results
+}
+
+func (item *searchItem) result() (SearchResult, bool) {
+	switch {
+	case item.VideoRenderer != nil:
+		v := item.VideoRenderer
+		result := SearchResult{
+			Kind:      ReferenceVideo,
+			ID:        v.VideoID,
+			Title:     v.Title.String(),
+			Author:    v.OwnerText.String(),
+			ChannelID: v.OwnerText.browseID(),
+			Duration:  parseClock(v.LengthText.String()),
+			Views:     parseCount(v.ViewCountText.String()),
+			Published: v.PublishedTimeText.String(),
+		}
+		for _, badge := range v.Badges {
+			if badge.MetadataBadgeRenderer.Style == "BADGE_STYLE_TYPE_LIVE_NOW" {
+				result.Live = true
+			}
+		}
+		return result, true
+
+	case item.ChannelRenderer != nil:
+		ch := item.ChannelRenderer
+		return SearchResult{
+			Kind:       ReferenceChannel,
+			ID:         ch.ChannelID,
+			Title:      ch.Title.String(),
+			Author:     ch.Title.String(),
+			ChannelID:  ch.ChannelID,
+			VideoCount: parseCount(ch.VideoCountText.String()),
+		}, true
+
+	case item.PlaylistRenderer != nil:
+		pl := item.PlaylistRenderer
+		return SearchResult{
+			Kind:       ReferencePlaylist,
+			ID:         pl.PlaylistID,
+			Title:      pl.Title.String(),
+			Author:     pl.ShortBylineText.String(),
+			ChannelID:  pl.ShortBylineText.browseID(),
+			VideoCount: parseCount(pl.VideoCount),
+		}, true
+	}
+	return SearchResult{}, false
+}
+
+// parseClock parses durations like 4:13 and 1:02:03
+func parseClock(value string) time.Duration {
+	var d time.Duration
+	for _, part := range strings.Split(value, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0
+		}
+		d = d*60 + time.Duration(n)
+	}
+	return d * time.Second
+}
+
+// parseCount reads the digits of texts like "1,234,567 views", "No views" is 0
+func parseCount(value string) int64 {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0
+	}
+
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, fields[0])
+	n, _ := strconv.ParseInt(digits, 10, 64)
+	return n
+}
@@ -0,0 +1,271 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const defaultDownloadWorkers = 4
+
+// DownloadTo downloads a specific format into w.
+// Chunks are requested in parallel and written directly to their offsets,
+// so w can be a file which is filled in any order.
+func (c *Client) DownloadTo(ctx context.Context, video *Video, format *Format, w io.WriterAt) error {
+	url, err := c.GetStreamURLContext(ctx, video, format)
+	if err != nil {
+		return err
+	}
+
+	size := format.ContentLength
+	if size == 0 {
+		if size, err = c.streamSize(ctx, url); err != nil {
+			return err
+		}
+	}
+
+	workers := c.DownloadWorkers
+	if workers <= 0 {
+		workers = defaultDownloadWorkers
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	perDownload := c.downloadRateLimiter()
+	tracker := c.newProgressTracker(video, format, size)
+	chunks := make(chan int64)
+	errs := make(chan error, workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for start := range chunks {
+				end := start + chunkSize - 1
+				if end >= size {
+					end = size - 1
+				}
+
+				if err := c.downloadRangeTo(ctx, url, start, end, size, w, perDownload, tracker); err != nil {
+					// stop the other workers as well
+					errs <- err
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+
+	var start int64
+feed:
+	for ; start < size; start += chunkSize {
+		select {
+		case chunks <- start:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(chunks)
+
+	wg.Wait()
+	close(errs)
+
+	// the first error is the cause, the others are cancellations
+	err = <-errs
+	// canceled by the caller before all chunks were handed out, the missing chunks must not look done
+	if err == nil && start < size {
+		err = ctx.Err()
+	}
+
+	tracker.finish(err)
+	return err
+}
+
+// downloadRangeTo downloads the bytes from start to end (inclusive) into w at the same offset
+func (c *Client) downloadRangeTo(ctx context.Context, url string, start, end, size int64, w io.WriterAt, perDownload *downloadBucket, tracker *progressTracker) error {
+	resp, err := c.getRange(ctx, url, start, end, size)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	tracker.startChunk(start)
+	written, err := io.Copy(&offsetWriter{w: w, offset: start}, tracker.reader(c.limitReader(ctx, resp.Body, perDownload)))
+	if err != nil {
+		return err
+	}
+
+	// the server may answer with less than requested, only at the end of the stream
+	if expected := end - start + 1; written != expected {
+		return ErrSizeMismatch{Offset: start, Expected: expected, Actual: written}
+	}
+	return nil
+}
+
+// streamSize asks the server for the total size of a stream
+func (c *Client) streamSize(ctx context.Context, url string) (int64, error) {
+	resp, err := c.getRange(ctx, url, 0, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	_, _, total, err := parseContentRange(resp.Header.Get("Content-Range"))
+	return total, err
+}
+
+// parseContentRange parses a header like "bytes 0-99/1000"
+func parseContentRange(header string) (start, end, total int64, err error) {
+	invalid := fmt.Errorf("invalid Content-Range header %q", header)
+
+	spec := strings.TrimPrefix(header, "bytes ")
+	slash := strings.IndexByte(spec, '/')
+	dash := strings.IndexByte(spec, '-')
+	if spec == header || slash < 0 || dash < 0 || dash > slash {
+		return 0, 0, 0, invalid
+	}
+
+	if start, err = strconv.ParseInt(spec[:dash], 10, 64); err != nil {
+		return 0, 0, 0, invalid
+	}
+	if end, err = strconv.ParseInt(spec[dash+1:slash], 10, 64); err != nil {
+		return 0, 0, 0, invalid
+	}
+	if total, err = strconv.ParseInt(spec[slash+1:], 10, 64); err != nil {
+		return 0, 0, 0, invalid
+	}
+
+	return start, end, total, nil
+}
+
+// offsetWriter writes sequentially into an io.WriterAt, starting at offset
+type offsetWriter struct {
+	w      io.WriterAt
+	offset int64
+}
+
+func (ow *offsetWriter) Write(p []byte) (int, error) {
+	n, err := ow.w.WriteAt(p, ow.offset)
+	ow.offset += int64(n)
+	return n, err
+}
+
+// Stream is a seekable stream of a format.
+// Data is requested lazily with Range requests starting at the current position,
+// seeking doesn't download anything until the next Read.
+type Stream struct {
+	client      *Client
+	ctx         context.Context
+	url         string
+	size        int64
+	pos         int64
+	body        io.ReadCloser
+	perDownload *downloadBucket
+}
+
+// OpenStream returns a seekable stream for a specific format
+func (c *Client) OpenStream(ctx context.Context, video *Video, format *Format) (*Stream, error) {
+	url, err := c.GetStreamURLContext(ctx, video, format)
+	if err != nil {
+		return nil, err
+	}
+
+	size := format.ContentLength
+	if size == 0 {
+		if size, err = c.streamSize(ctx, url); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Stream{
+		client:      c,
+		ctx:         ctx,
+		url:         url,
+		size:        size,
+		perDownload: c.downloadRateLimiter(),
+	}, nil
+}
+
+// Size returns the total size of the stream
+func (s *Stream) Size() int64 {
+	return s.size
+}
+
+// Read reads from the current position, the next chunk is requested when needed
+func (s *Stream) Read(p []byte) (int, error) {
+	if s.pos >= s.size {
+		return 0, io.EOF
+	}
+
+	if s.body == nil {
+		end := s.pos + chunkSize - 1
+		if end >= s.size {
+			end = s.size - 1
+		}
+
+		resp, err := s.client.getRange(s.ctx, s.url, s.pos, end, s.size)
+		if err != nil {
+			return 0, err
+		}
+		s.body = resp.Body
+	}
+
+	n, err := s.client.limitReader(s.ctx, s.body, s.perDownload).Read(p)
+	s.pos += int64(n)
+
+	// the chunk is finished, continue with the next one on the next Read
+	if err == io.EOF {
+		s.body.Close()
+		s.body = nil
+		if s.pos < s.size {
+			err = nil
+		}
+	}
+
+	return n, err
+}
+
+// Seek sets the position of the next Read
+func (s *Stream) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = s.pos + offset
+	case io.SeekEnd:
+		pos = s.size + offset
+	default:
+		return s.pos, errors.New("invalid whence")
+	}
+
+	if pos < 0 {
+		return s.pos, errors.New("negative position")
+	}
+
+	if pos != s.pos && s.body != nil {
+		s.body.Close()
+		s.body = nil
+	}
+	s.pos = pos
+
+	return pos, nil
+}
+
+// Close releases the open connection
+func (s *Stream) Close() error {
+	if s.body == nil {
+		return nil
+	}
+
+	err := s.body.Close()
+	s.body = nil
+	return err
+}
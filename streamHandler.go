@@ -0,0 +1,271 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultStreamURLLifetime is used for stream URLs without an expire parameter
+	defaultStreamURLLifetime = time.Hour
+	// streamURLExpiryMargin renews URLs a bit before they expire
+	streamURLExpiryMargin = time.Minute
+)
+
+// streamHeaders are copied from the upstream response to the viewer
+var streamHeaders = []string{"Accept-Ranges", "Content-Length", "Content-Range", "Content-Type", "ETag", "Last-Modified"}
+
+// StreamHandler is an http.Handler streaming videos from YouTube to its clients at /watch/{id}?itag=...,
+// without itag the first format is streamed. Range requests are passed on, so viewers can seek.
+// Resolved stream URLs are shared by all viewers of a format and renewed when they expire.
+// A StreamHandler with a Client is ready to use, NewStreamHandler is a shorthand.
+type StreamHandler struct {
+	Client *Client
+
+	// getVideo fetches the video, it's replaced in tests. Without it the Client is asked.
+	getVideo func(ctx context.Context, id string) (*Video, error)
+
+	mu   sync.Mutex
+	urls map[streamKey]*resolvedStream
+}
+
+type streamKey struct {
+	videoID string
+	itag    int
+}
+
+// resolvedStream is a resolved stream URL, ready is closed once url or err is set
+type resolvedStream struct {
+	ready   chan struct{}
+	url     string
+	format  *Format
+	expires time.Time
+	err     error
+}
+
+// NewStreamHandler returns a StreamHandler using the client for YouTube requests
+func NewStreamHandler(c *Client) *StreamHandler {
+	return &StreamHandler{Client: c}
+}
+
+// ServeHTTP streams the requested format, GET and HEAD are supported
+func (h *StreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/watch/")
+	if id == r.URL.Path || id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	id, err := ExtractVideoID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var itag int
+	if value := r.URL.Query().Get("itag"); value != "" {
+		if itag, err = strconv.Atoi(value); err != nil {
+			http.Error(w, "invalid itag", http.StatusBadRequest)
+			return
+		}
+	}
+
+	key := streamKey{videoID: id, itag: itag}
+	resp, format, err := h.openUpstream(r, key)
+	if err != nil {
+		http.Error(w, err.Error(), streamErrorStatus(err))
+		return
+	}
+	defer resp.Body.Close()
+
+	for _, name := range streamHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			w.Header().Set(name, value)
+		}
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", format.MimeType)
+	}
+	w.WriteHeader(resp.StatusCode)
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	// a viewer going away cancels the request context, which ends the copy
+	body := h.Client.limitReader(r.Context(), resp.Body, h.Client.downloadRateLimiter())
+	io.Copy(w, body)
+}
+
+// openUpstream requests the stream with the Range header of the viewer.
+// An expired URL is resolved again once.
+func (h *StreamHandler) openUpstream(r *http.Request, key streamKey) (*http.Response, *Format, error) {
+	for attempt := 1; ; attempt++ {
+		stream, err := h.resolve(r.Context(), key)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		req, err := http.NewRequestWithContext(r.Context(), r.Method, stream.url, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+
+		resp, err := h.Client.httpDo(req)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch resp.StatusCode {
+		case http.StatusOK, http.StatusPartialContent, http.StatusRequestedRangeNotSatisfiable:
+			return resp, stream.format, nil
+
+		case http.StatusForbidden, http.StatusGone:
+			// the URL expired or was bound to something that changed
+			resp.Body.Close()
+			h.forget(key, stream)
+			if attempt < 2 {
+				continue
+			}
+		}
+
+		resp.Body.Close()
+		return nil, nil, ErrUnexpectedHTTPStatusCode(resp.StatusCode)
+	}
+}
+
+// resolve returns the stream URL of the format, concurrent viewers wait for the same resolution
+func (h *StreamHandler) resolve(ctx context.Context, key streamKey) (*resolvedStream, error) {
+	h.mu.Lock()
+	stream, ok := h.urls[key]
+	if ok {
+		select {
+		case <-stream.ready:
+			if stream.err != nil || time.Now().After(stream.expires) {
+				ok = false
+			}
+		default:
+			// being resolved by another viewer
+		}
+	}
+	if !ok {
+		h.pruneLocked()
+		if h.urls == nil {
+			h.urls = make(map[streamKey]*resolvedStream)
+		}
+		stream = &resolvedStream{ready: make(chan struct{})}
+		h.urls[key] = stream
+		go h.fill(stream, key)
+	}
+	h.mu.Unlock()
+
+	select {
+	case <-stream.ready:
+		return stream, stream.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fill resolves the URL, independent of the viewer who asked first
+func (h *StreamHandler) fill(stream *resolvedStream, key streamKey) {
+	defer close(stream.ready)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	getVideo := h.getVideo
+	if getVideo == nil {
+		getVideo = h.Client.GetVideoContext
+	}
+
+	video, err := getVideo(ctx, key.videoID)
+	if err != nil {
+		stream.err = err
+		return
+	}
+
+	if key.itag == 0 {
+		if len(video.Formats) == 0 {
+			stream.err = errors.New("no formats found")
+			return
+		}
+		stream.format = &video.Formats[0]
+	} else {
+		formats := video.Formats.Itag(key.itag)
+		if len(formats) == 0 {
+			stream.err = fmt.Errorf("%w: itag %d", ErrNoFormatMatches, key.itag)
+			return
+		}
+		stream.format = &formats[0]
+	}
+
+	if stream.url, stream.err = h.Client.GetStreamURLContext(ctx, video, stream.format); stream.err != nil {
+		return
+	}
+	stream.expires = streamURLExpiry(stream.url, time.Now())
+}
+
+// pruneLocked drops expired and failed URLs, h.mu must be held
+func (h *StreamHandler) pruneLocked() {
+	now := time.Now()
+	for key, stream := range h.urls {
+		select {
+		case <-stream.ready:
+			if stream.err != nil || now.After(stream.expires) {
+				delete(h.urls, key)
+			}
+		default:
+		}
+	}
+}
+
+// forget drops an expired URL, unless it was replaced already
+func (h *StreamHandler) forget(key streamKey, stream *resolvedStream) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.urls[key] == stream {
+		delete(h.urls, key)
+	}
+}
+
+// streamURLExpiry reads the expire parameter of a stream URL
+func streamURLExpiry(rawURL string, now time.Time) time.Time {
+	if uri, err := url.Parse(rawURL); err == nil {
+		if expire, err := strconv.ParseInt(uri.Query().Get("expire"), 10, 64); err == nil {
+			return time.Unix(expire, 0).Add(-streamURLExpiryMargin)
+		}
+	}
+	return now.Add(defaultStreamURLLifetime)
+}
+
+// streamErrorStatus maps errors of resolving a stream to a status code
+func streamErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrNoFormatMatches):
+		return http.StatusNotFound
+	case errors.Is(err, ErrVideoPrivate):
+		return http.StatusForbidden
+	case errors.Is(err, context.Canceled):
+		// the viewer is gone, nobody reads the answer
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadGateway
+}
@@ -0,0 +1,63 @@
+package youtube
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.jsonl")
+
+	archive, err := OpenFileArchive(path)
+	require.NoError(t, err)
+
+	_, ok, err := archive.Lookup("BaW_jenozKc", 18)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, archive.Add(ArchiveEntry{VideoID: "BaW_jenozKc", ItagNo: 18, Path: "a.mp4"}))
+	require.NoError(t, archive.Add(ArchiveEntry{VideoID: "BaW_jenozKc", ItagNo: 22, Path: "b.mp4"}))
+	require.NoError(t, archive.Add(ArchiveEntry{VideoID: "BaW_jenozKc", ItagNo: 22, Path: "c.mp4", SHA256: "abc"}))
+	require.NoError(t, archive.Close())
+
+	// simulate a crash in the middle of writing an entry
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"id":"450p7goxZqg","it`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	archive, err = OpenFileArchive(path)
+	require.NoError(t, err)
+	defer archive.Close()
+
+	// the formats of a video are separate entries, the latest one of a format counts
+	entry, ok, err := archive.Lookup("BaW_jenozKc", 22)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "c.mp4", entry.Path)
+	assert.Equal(t, "abc", entry.SHA256)
+	assert.False(t, entry.Time.IsZero())
+
+	entry, ok, _ = archive.Lookup("BaW_jenozKc", 18)
+	assert.True(t, ok)
+	assert.Equal(t, "a.mp4", entry.Path)
+
+	_, ok, _ = archive.Lookup("BaW_jenozKc", 140)
+	assert.False(t, ok)
+	_, ok, _ = archive.Lookup("450p7goxZqg", 0)
+	assert.False(t, ok)
+
+	// entries after the cut off line are readable again
+	require.NoError(t, archive.Add(ArchiveEntry{VideoID: "Jl8fV1jUQPs", ItagNo: 140}))
+	reopened, err := OpenFileArchive(path)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	_, ok, _ = reopened.Lookup("Jl8fV1jUQPs", 140)
+	assert.True(t, ok)
+}
@@ -1,63 +1,216 @@
-package youtube
-
-import (
-	"testing"
-)
-
-const (
-	dwlURL    string = "https://www.youtube.com/watch?v=rFejpH_tAHM"
-	streamURL string = "https://www.youtube.com/watch?v=5qap5aO4i9A"
-	errURL    string = "https://www.youtube.com/watch?v=I8oGsuQ"
-)
-
-func TestYoutube_findVideoID(t *testing.T) {
-	type args struct {
-		url string
-	}
-	tests := []struct {
-		name        string
-		args        args
-		wantErr     bool
-		expectedErr error
-	}{
-		{
-			name: "valid url",
-			args: args{
-				dwlURL,
-			},
-			wantErr:     false,
-			expectedErr: nil,
-		},
-		{
-			name: "valid id",
-			args: args{
-				"rFejpH_tAHM",
-			},
-			wantErr:     false,
-			expectedErr: nil,
-		},
-		{
-			name: "invalid character in id",
-			args: args{
-				"<M1!3",
-			},
-			wantErr:     true,
-			expectedErr: ErrInvalidCharactersInVideoID,
-		},
-		{
-			name: "video id is less than 10 characters",
-			args: args{
-				"afasda",
-			},
-			wantErr:     true,
-			expectedErr: ErrVideoIDMinLength,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if _, err := ExtractVideoID(tt.args.url); (err != nil) != tt.wantErr || err != tt.expectedErr {
-				t.Errorf("extractVideoID() error = %v, wantErr %v", err, tt.wantErr)
-			}
-		})
-	}
-}
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yigitcilce/youtube/youtubetest"
+)
+
+const (
+	dwlURL    string = "https://www.youtube.com/watch?v=rFejpH_tAHM"
+	streamURL string = "https://www.youtube.com/watch?v=5qap5aO4i9A"
+	errURL    string = "https://www.youtube.com/watch?v=I8oGsuQ"
+)
+
+func TestYoutube_findVideoID(t *testing.T) {
+	type args struct {
+		url string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantErr     bool
+		expectedErr error
+	}{
+		{
+			name: "valid url",
+			args: args{
+				dwlURL,
+			},
+			wantErr:     false,
+			expectedErr: nil,
+		},
+		{
+			name: "valid id",
+			args: args{
+				"rFejpH_tAHM",
+			},
+			wantErr:     false,
+			expectedErr: nil,
+		},
+		{
+			name: "invalid character in id",
+			args: args{
+				"<M1!3",
+			},
+			wantErr:     true,
+			expectedErr: ErrInvalidCharactersInVideoID,
+		},
+		{
+			name: "video id is less than 10 characters",
+			args: args{
+				"afasda",
+			},
+			wantErr:     true,
+			expectedErr: ErrVideoIDMinLength,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ExtractVideoID(tt.args.url); (err != nil) != tt.wantErr || err != tt.expectedErr {
+				t.Errorf("extractVideoID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// newFakeClient returns a client talking to a fake YouTube with a plain and a ciphered video
+func newFakeClient(t *testing.T) (*Client, *youtubetest.Server, []byte) {
+	content := bytes.Repeat([]byte("0123456789"), 2500)
+	fake := youtubetest.NewServer(
+		youtubetest.Video{ID: "BaW_jenozKc", Title: "plain", Author: "author", Duration: 10 * time.Second, Content: content},
+		youtubetest.Video{ID: "5qap5aO4i9A", Title: "ciphered", Content: content, Ciphered: true},
+	)
+	t.Cleanup(fake.Close)
+
+	return &Client{BaseURL: fake.URL, ThumbnailBaseURL: fake.URL}, fake, content
+}
+
+func TestClient_GetVideo(t *testing.T) {
+	c, fake, content := newFakeClient(t)
+
+	video, err := c.GetVideoContext(context.Background(), "https://www.youtube.com/watch?v=BaW_jenozKc")
+	require.NoError(t, err)
+	assert.Equal(t, "plain", video.Title)
+	assert.Equal(t, "author", video.Author)
+	assert.Equal(t, 10*time.Second, video.Duration)
+	require.Len(t, video.Formats, 2)
+	assert.Equal(t, int64(len(content)), video.Formats[0].ContentLength)
+
+	// the player is cached
+	_, err = c.GetVideoContext(context.Background(), "5qap5aO4i9A")
+	require.NoError(t, err)
+	assert.Equal(t, 1, fake.Requests("player"))
+	assert.Equal(t, 2, fake.Requests("innertube"))
+
+	_, err = c.GetVideoContext(context.Background(), "unknown0000")
+	assert.Error(t, err)
+}
+
+func TestClient_GetStream(t *testing.T) {
+	c, _, content := newFakeClient(t)
+
+	// the fake rejects URLs with a wrong signature or n-parameter
+	for _, id := range []string{"BaW_jenozKc", "5qap5aO4i9A"} {
+		t.Run(id, func(t *testing.T) {
+			video, err := c.GetVideoContext(context.Background(), id)
+			require.NoError(t, err)
+
+			stream, size, err := c.GetStreamContext(context.Background(), video, &video.Formats[0])
+			require.NoError(t, err)
+			defer stream.Close()
+
+			got, err := io.ReadAll(stream)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(content)), size)
+			assert.Equal(t, content, got)
+		})
+	}
+}
+
+func TestClient_GetStreamHash(t *testing.T) {
+	c, _, content := newFakeClient(t)
+	video, err := c.GetVideoContext(context.Background(), "BaW_jenozKc")
+	require.NoError(t, err)
+
+	checksum := sha256.New()
+	stream, _, err := c.GetStreamHashContext(context.Background(), video, &video.Formats[0], checksum)
+	require.NoError(t, err)
+	defer stream.Close()
+
+	got, err := io.ReadAll(stream)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+	want := sha256.Sum256(content)
+	assert.Equal(t, want[:], checksum.Sum(nil))
+}
+
+func TestClient_DownloadTo(t *testing.T) {
+	c, _, content := newFakeClient(t)
+	video, err := c.GetVideoContext(context.Background(), "5qap5aO4i9A")
+	require.NoError(t, err)
+
+	out, err := os.CreateTemp(t.TempDir(), "video")
+	require.NoError(t, err)
+	defer out.Close()
+
+	require.NoError(t, c.DownloadTo(context.Background(), video, &video.Formats[1], out))
+	written, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	assert.Equal(t, content, written)
+}
+
+func TestClient_ThumbnailBaseURL(t *testing.T) {
+	c, fake, _ := newFakeClient(t)
+
+	image, size, err := c.GetThumbnail(context.Background(), &Video{ID: "BaW_jenozKc"}, ThumbnailMaxRes)
+	require.NoError(t, err)
+	assert.Equal(t, ThumbnailHigh, size)
+	assert.Equal(t, youtubetest.Thumbnail, image)
+
+	// the thumbnails of a video point to the same address
+	video, err := c.GetVideoContext(context.Background(), "BaW_jenozKc")
+	require.NoError(t, err)
+	require.Len(t, video.Thumbnails, 5)
+	for _, thumbnail := range video.Thumbnails {
+		assert.True(t, strings.HasPrefix(thumbnail.URL, fake.URL+"/vi/BaW_jenozKc/"), thumbnail.URL)
+	}
+}
+
+func TestClient_RecordReplay(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 2500)
+	fake := youtubetest.NewServer(youtubetest.Video{ID: "5qap5aO4i9A", Title: "ciphered", Content: content, Ciphered: true})
+	dir := t.TempDir()
+
+	session := func(transport http.RoundTripper) (*Video, []byte) {
+		c := &Client{BaseURL: fake.URL, HTTPClient: &http.Client{Transport: transport}}
+		video, err := c.GetVideoContext(context.Background(), "5qap5aO4i9A")
+		require.NoError(t, err)
+
+		stream, _, err := c.GetStreamContext(context.Background(), video, &video.Formats[0])
+		require.NoError(t, err)
+		defer stream.Close()
+
+		got, err := io.ReadAll(stream)
+		require.NoError(t, err)
+		return video, got
+	}
+
+	recorder, err := youtubetest.NewRecorder(dir, nil)
+	require.NoError(t, err)
+	recorder.MaxStreamBody = 1000
+	recorded, got := session(recorder)
+	assert.Equal(t, content, got)
+
+	// the replay needs no server at all
+	fake.Close()
+	replayer, err := youtubetest.NewReplayer(dir)
+	require.NoError(t, err)
+	replayed, got := session(replayer)
+	assert.Equal(t, recorded.Title, replayed.Title)
+	assert.Equal(t, recorded.Formats[0].ItagNo, replayed.Formats[0].ItagNo)
+	// the truncated stream is replayed as a complete stream of the recorded size
+	assert.Equal(t, int64(1000), replayed.Formats[0].ContentLength)
+	assert.Equal(t, content[:1000], got)
+}
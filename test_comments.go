@@ -0,0 +1,127 @@
+package youtube
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yigitcilce/youtube/youtubetest"
+)
+
+func fakeComments() []youtubetest.Comment {
+	return []youtubetest.Comment{
+		{ID: "c1", Author: "@first", Text: "pinned by the creator", Likes: 1234, Published: "2 days ago", Pinned: true, Hearted: true},
+		{ID: "c2", Author: "@second", Text: "great video", Likes: 15, Published: "1 week ago (edited)", Replies: []youtubetest.Comment{
+			{ID: "c2.r1", Author: "@third", Text: "agreed", Published: "6 days ago"},
+			{ID: "c2.r2", Author: "@fourth", Text: "same", Published: "5 days ago"},
+			{ID: "c2.r3", Author: "@fifth", Text: "me too", Likes: 2, Published: "3 hours ago"},
+		}},
+		{ID: "c3", Author: "@sixth", Text: "first", Published: "1 month ago"},
+	}
+}
+
+func collectComments(t *testing.T, it *CommentIterator) []Comment {
+	var comments []Comment
+	for it.Next() {
+		comments = append(comments, it.Comment())
+	}
+	require.NoError(t, it.Err())
+	return comments
+}
+
+func commentIDs(comments []Comment) []string {
+	ids := make([]string, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+	}
+	return ids
+}
+
+func TestClient_GetComments(t *testing.T) {
+	c, fake, _ := newFakeClient(t)
+	fake.AddVideo(youtubetest.Video{ID: "aaaaaaaaaaa", Title: "Gopher", Comments: fakeComments()})
+	ctx := context.Background()
+
+	t.Run("top", func(t *testing.T) {
+		before := time.Now()
+		comments := collectComments(t, c.GetComments(ctx, "aaaaaaaaaaa", nil))
+		assert.Equal(t, []string{"c1", "c2", "c3"}, commentIDs(comments))
+
+		first := comments[0]
+		assert.Equal(t, "pinned by the creator", first.Text)
+		assert.Equal(t, "@first", first.Author)
+		assert.Equal(t, "UCc1", first.AuthorChannelID)
+		assert.Equal(t, 1200, first.Likes)
+		assert.True(t, first.Pinned)
+		assert.True(t, first.Hearted)
+		assert.False(t, first.Edited)
+		assert.Equal(t, "2 days ago", first.Published)
+		assert.WithinDuration(t, before.Add(-48*time.Hour), first.PublishedAt, time.Minute)
+
+		second := comments[1]
+		assert.Equal(t, 3, second.ReplyCount)
+		assert.True(t, second.Edited)
+		assert.Equal(t, "1 week ago", second.Published)
+		assert.False(t, second.Pinned)
+	})
+
+	t.Run("newest with replies", func(t *testing.T) {
+		comments := collectComments(t, c.GetComments(ctx, "https://youtu.be/aaaaaaaaaaa", &CommentOptions{
+			Sort:    CommentSortNewest,
+			Replies: true,
+		}))
+		assert.Equal(t, []string{"c3", "c2", "c2.r1", "c2.r2", "c2.r3", "c1"}, commentIDs(comments))
+		assert.Equal(t, "", comments[1].ParentID)
+		assert.Equal(t, "c2", comments[4].ParentID)
+		assert.Equal(t, 2, comments[4].Likes)
+	})
+
+	t.Run("lazy", func(t *testing.T) {
+		requests := fake.Requests("next")
+		it := c.GetComments(ctx, "aaaaaaaaaaa", nil)
+		assert.Equal(t, requests, fake.Requests("next"))
+
+		require.True(t, it.Next())
+		// the watch page and the first page of comments
+		assert.Equal(t, requests+2, fake.Requests("next"))
+	})
+}
+
+func TestClient_GetComments_Errors(t *testing.T) {
+	c, fake, _ := newFakeClient(t)
+	fake.AddVideo(youtubetest.Video{ID: "aaaaaaaaaaa", Title: "Gopher"})
+	ctx := context.Background()
+
+	it := c.GetComments(ctx, "aaaaaaaaaaa", nil)
+	assert.False(t, it.Next())
+	assert.ErrorIs(t, it.Err(), ErrCommentsDisabled)
+
+	it = c.GetComments(ctx, "invalid", nil)
+	assert.False(t, it.Next())
+	assert.ErrorIs(t, it.Err(), ErrVideoIDMinLength)
+
+	it = c.GetComments(ctx, "aaaaaaaaaaa", &CommentOptions{Sort: "oldest"})
+	assert.False(t, it.Next())
+	assert.Error(t, it.Err())
+}
+
+func TestParseShortCount(t *testing.T) {
+	for value, want := range map[string]int64{"": 0, "15": 15, "1,234": 1234, "1.2K": 1200, "3M": 3000000, "3.2B": 3200000000, "likes": 0} {
+		assert.Equal(t, want, parseShortCount(value), value)
+	}
+}
+
+func TestParseTimeAgo(t *testing.T) {
+	for value, want := range map[string]time.Duration{
+		"1 second ago": time.Second,
+		"3 hours ago":  3 * time.Hour,
+		"2 weeks ago":  14 * 24 * time.Hour,
+		"1 year ago":   365 * 24 * time.Hour,
+		"Streamed":     0,
+	} {
+		assert.Equal(t, want, parseTimeAgo(value), value)
+	}
+}
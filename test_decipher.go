@@ -0,0 +1,62 @@
+package youtube
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_unThrottleURL(t *testing.T) {
+	c, fake, _ := newFakeClient(t)
+
+	// without n-parameter the URL is kept and the player is not fetched
+	plain := fake.URL + "/videoplayback?id=BaW_jenozKc&itag=18"
+	got, err := c.unThrottleURL(context.Background(), "BaW_jenozKc", plain)
+	require.NoError(t, err)
+	assert.Equal(t, plain, got)
+	assert.Equal(t, 0, fake.Requests("player"))
+
+	// the n function of the fake player reverses the parameter
+	got, err = c.unThrottleURL(context.Background(), "BaW_jenozKc", fake.URL+"/videoplayback?id=BaW_jenozKc&itag=18&n=abc")
+	require.NoError(t, err)
+	uri, err := url.Parse(got)
+	require.NoError(t, err)
+	assert.Equal(t, "cba", uri.Query().Get("n"))
+	assert.Equal(t, "18", uri.Query().Get("itag"))
+	assert.Equal(t, 1, fake.Requests("player"))
+
+	_, err = c.unThrottleURL(context.Background(), "BaW_jenozKc", "%zz")
+	assert.Error(t, err)
+}
+
+func TestClient_GetStreamURL_NParameter(t *testing.T) {
+	c, _, _ := newFakeClient(t)
+
+	// unciphered URLs are throttled as well, both kinds get the decoded n-parameter
+	for _, id := range []string{"BaW_jenozKc", "5qap5aO4i9A"} {
+		t.Run(id, func(t *testing.T) {
+			video, err := c.GetVideoContext(context.Background(), id)
+			require.NoError(t, err)
+
+			got, err := c.GetStreamURLContext(context.Background(), video, &video.Formats[0])
+			require.NoError(t, err)
+			uri, err := url.Parse(got)
+			require.NoError(t, err)
+			assert.Equal(t, "delttorht-"+reverseString(id)+"-n", uri.Query().Get("n"))
+		})
+	}
+
+	_, err := c.GetStreamURLContext(context.Background(), &Video{ID: "BaW_jenozKc"}, &Format{})
+	assert.ErrorIs(t, err, ErrCipherNotFound)
+}
+
+func reverseString(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
@@ -0,0 +1,87 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fallbackPlayers have helpers the operations don't know, they are deciphered with JavaScript
+var fallbackPlayers = map[string]bool{"rotate-helper": true}
+
+// TestPlayerCorpus checks the extraction against the players in testdata/players,
+// every directory holds a base.js with the known results in cases.json
+func TestPlayerCorpus(t *testing.T) {
+	players, err := filepath.Glob(filepath.Join("testdata", "players", "*", "base.js"))
+	require.NoError(t, err)
+	require.NotEmpty(t, players)
+
+	for _, player := range players {
+		dir := filepath.Dir(player)
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			js, err := os.ReadFile(player)
+			require.NoError(t, err)
+
+			data, err := os.ReadFile(filepath.Join(dir, "cases.json"))
+			require.NoError(t, err)
+			var cases PlayerCases
+			require.NoError(t, json.Unmarshal(data, &cases))
+			require.NotEmpty(t, cases.Signature)
+			require.NotEmpty(t, cases.N)
+
+			var names []string
+			for _, step := range CheckPlayer(js, cases) {
+				names = append(names, step.Name)
+				if fallbackPlayers[filepath.Base(dir)] && step.Optional {
+					continue
+				}
+				assert.NoError(t, step.Err, step.Name)
+			}
+			assert.Contains(t, names, "signature cases")
+			assert.Contains(t, names, "n cases")
+		})
+	}
+}
+
+func TestCheckPlayer_BrokenStep(t *testing.T) {
+	js, err := os.ReadFile(filepath.Join("testdata", "players", "classic-operations", "base.js"))
+	require.NoError(t, err)
+
+	// a cut-off player, the n function is missing
+	broken := []byte(string(js[:len(js)/2]))
+	steps := CheckPlayer(broken, PlayerCases{})
+
+	last := steps[len(steps)-1]
+	assert.Equal(t, "n function name", last.Name)
+	assert.Error(t, last.Err)
+}
+
+func TestDecrypt_JavascriptFallback(t *testing.T) {
+	js, err := os.ReadFile(filepath.Join("testdata", "players", "rotate-helper", "base.js"))
+	require.NoError(t, err)
+	config := playerConfig(js)
+
+	_, err = config.parseDecipherOps()
+	require.Error(t, err)
+
+	input := "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	output, err := config.decrypt(context.Background(), []byte(input), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "owvutsrqpxnmlkjihgfedcbaZYXWVUTS5NMLKJIHGFEDCBAZYXWVUTSRQPONMLKJIHGFEDCBA_-9876R43210zy", string(output))
+
+	// both ways give the same result for the players the operations know
+	js, err = os.ReadFile(filepath.Join("testdata", "players", "classic-operations", "base.js"))
+	require.NoError(t, err)
+	config = playerConfig(js)
+
+	viaOperations, err := config.decrypt(context.Background(), []byte(input), nil)
+	require.NoError(t, err)
+	viaJavascript, err := config.decryptJavascript(context.Background(), []byte(input), nil)
+	require.NoError(t, err)
+	assert.Equal(t, string(viaOperations), string(viaJavascript))
+}
@@ -1,40 +1,86 @@
-package youtube
-
-import (
-	"testing"
-
-	"github.com/stretchr/testify/assert"
-)
-
-func TestFormatList_Type(t *testing.T) {
-	list := []Format{{
-		MimeType: "video/mp4; codecs=\"avc1.42001E, mp4a.40.2\"",
-	},
-	}
-	type args struct {
-		mimeType string
-	}
-	tests := []struct {
-		name string
-		list FormatList
-		args args
-		want FormatList
-	}{
-		{
-			name: "find video",
-			list: list,
-			args: args{
-				mimeType: "video/mp4; codecs=\"avc1.42001E, mp4a.40.2\"",
-			},
-			want: []Format{{
-				MimeType: "video/mp4; codecs=\"avc1.42001E, mp4a.40.2\"",
-			}},
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			format := tt.list.Type("video")
-			assert.Equal(t, format, tt.want)
-		})
-	}
-}
+package youtube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatList_Type(t *testing.T) {
+	list := []Format{{
+		MimeType: "video/mp4; codecs=\"avc1.42001E, mp4a.40.2\"",
+	},
+	}
+	type args struct {
+		mimeType string
+	}
+	tests := []struct {
+		name string
+		list FormatList
+		args args
+		want FormatList
+	}{
+		{
+			name: "find video",
+			list: list,
+			args: args{
+				mimeType: "video/mp4; codecs=\"avc1.42001E, mp4a.40.2\"",
+			},
+			want: []Format{{
+				MimeType: "video/mp4; codecs=\"avc1.42001E, mp4a.40.2\"",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format := tt.list.Type("video")
+			assert.Equal(t, format, tt.want)
+		})
+	}
+}
+
+func TestFormatList_Select(t *testing.T) {
+	list := FormatList{
+		{ItagNo: 18, MimeType: "video/mp4; codecs=\"avc1.42001E, mp4a.40.2\"", Height: 360, Bitrate: 500000, AudioChannels: 2},
+		{ItagNo: 22, MimeType: "video/mp4; codecs=\"avc1.64001F, mp4a.40.2\"", Height: 720, Bitrate: 1500000, AudioChannels: 2},
+		{ItagNo: 137, MimeType: "video/mp4; codecs=\"avc1.640028\"", Height: 1080, Bitrate: 4000000},
+		{ItagNo: 248, MimeType: "video/webm; codecs=\"vp9\"", Height: 1080, Bitrate: 3000000},
+		{ItagNo: 140, MimeType: "audio/mp4; codecs=\"mp4a.40.2\"", Bitrate: 130000, AudioChannels: 2},
+		{ItagNo: 251, MimeType: "audio/webm; codecs=\"opus\"", Bitrate: 160000, AudioChannels: 2},
+	}
+
+	tests := []struct {
+		selector string
+		want     int
+		wantErr  error
+	}{
+		{selector: "best", want: 22},
+		{selector: "worst", want: 18},
+		{selector: "bestaudio", want: 251},
+		{selector: "bestaudio[ext=mp4]", want: 140},
+		{selector: "bestvideo", want: 137},
+		{selector: "bestvideo[codec=vp9]", want: 248},
+		{selector: "best[height<=480]", want: 18},
+		{selector: "137", want: 137},
+		{selector: "webm", want: 248},
+		{selector: "bestvideo[height>1080]/best", want: 22},
+		{selector: "bestvideo[height>1080]", wantErr: ErrNoFormatMatches},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			format, err := list.Select(tt.selector)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, format.ItagNo)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"bestest", "best[height~720]", "best[height=hd]", "best[ext>mp4]"} {
+		_, err := list.Select(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,87 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvalJavascript(t *testing.T) {
+	ctx := context.Background()
+
+	output, err := evalJavascript(ctx, `function(a){return a.split("").reverse().join("")}`, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "cba", output)
+
+	tests := []struct {
+		name string
+		js   string
+	}{
+		{name: "exception", js: `function(a){throw new Error("broken")}`},
+		{name: "reference error", js: `function(a){return missing(a)}`},
+		{name: "not a string", js: `function(a){return 42}`},
+		{name: "not a function", js: `"abc"`},
+		{name: "syntax error", js: `function(a){`},
+		{name: "recursion", js: `function f(a){return f(a)+a}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := evalJavascript(ctx, tt.js, "abc")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEvalJavascript_Interrupt(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := evalJavascript(ctx, `function(a){for(;;){}}`, "abc")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+	assert.Less(t, time.Since(start), javascriptTimeout)
+
+	// also while compiling
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = evalJavascript(ctx, `(function(){for(;;){}})()`, "abc")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+}
+
+func TestEvalJavascript_FreshState(t *testing.T) {
+	const js = `(function(){var calls=0;return function(a){calls++;leaked=(typeof leaked==="undefined"?"":leaked)+a;return a+calls+leaked}})()`
+	ctx := context.Background()
+
+	// neither the closure nor the globals keep state between calls
+	for i := 0; i < 3; i++ {
+		output, err := evalJavascript(ctx, js, "x")
+		require.NoError(t, err)
+		assert.Equal(t, "x1x", output)
+	}
+	assert.Contains(t, javascriptPrograms.programs, js, "the expression is compiled once")
+
+	// a failing call doesn't affect the next one
+	const fails = `function(a){if(a==="bad")throw a;return a}`
+	_, err := evalJavascript(ctx, fails, "bad")
+	assert.Error(t, err)
+	output, err := evalJavascript(ctx, fails, "good")
+	require.NoError(t, err)
+	assert.Equal(t, "good", output)
+}
+
+func TestEvalJavascript_OutputLimit(t *testing.T) {
+	_, err := evalJavascript(context.Background(), `function(a){while(a.length<=65536){a+=a}return a}`, "abc")
+	assert.Error(t, err)
+}
+
+func TestDecodeNsig_Failed(t *testing.T) {
+	config := playerConfig(`var Nf=function(a){try{return a.missing()}catch(b){return"enhanced_except_gZYB_w8_"+a}};
+g.Qq=function(a){var b;a.url&&(b=a.get("n"))&&(b=Nf(b),a.set("n",b))};`)
+
+	_, err := config.decodeNsig(context.Background(), "abc", nil)
+	assert.True(t, errors.Is(err, ErrNFunctionFailed), err)
+}
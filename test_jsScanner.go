@@ -0,0 +1,103 @@
+package youtube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClosingBracket(t *testing.T) {
+	tests := []struct {
+		name string
+		js   string
+		want string
+	}{
+		{name: "nested", js: `{a{b}c}d`, want: `{a{b}c}`},
+		{name: "strings", js: `{a="}";b='{'}x`, want: `{a="}";b='{'}`},
+		{name: "escaped quote", js: `{a="\"}"}x`, want: `{a="\"}"}`},
+		{name: "template", js: "{a=`}${b({})}{`}x", want: "{a=`}${b({})}{`}"},
+		{name: "regexp", js: `{a=/[}/]\}/g.test(b)}x`, want: `{a=/[}/]\}/g.test(b)}`},
+		{name: "division", js: `{a=b/c/d;e={}}x`, want: `{a=b/c/d;e={}}`},
+		{name: "comments", js: "{a=1;// }\n/* } */b=2}x", want: "{a=1;// }\n/* } */b=2}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end, err := closingBracket([]byte(tt.js), 0)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tt.js[:end])
+		})
+	}
+}
+
+func TestClosingBracket_Unterminated(t *testing.T) {
+	for _, js := range []string{`{a{b}`, `{a="}`, "{a=`${", `{/* }`, `{a=/}`} {
+		_, err := closingBracket([]byte(js), 0)
+		assert.Error(t, err, js)
+	}
+
+	_, err := closingBracket([]byte(`{}`), 2)
+	assert.Error(t, err)
+}
+
+func TestFreeIdentifiers(t *testing.T) {
+	js := `function(a,b){var c=a.split(""),d={e:Ab,f:1};for(var i=0;i<c.length;i++)c[i]=Cd(c[i],x=>x+Ef);return typeof Gh==="undefined"?String(c):c.join("")}`
+	names, err := freeIdentifiers([]byte(js))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Ab", "Cd", "Ef", "Gh"}, names)
+}
+
+func TestStandaloneFunction(t *testing.T) {
+	js := []byte(`var _yt_player={};(function(g){var Ab=["}",3],Cd;
+function Ef(a){return a+Ab[0]}
+Cd=function(a){var b=function(c){return Ef(c)};return b(a)+Ab[1]};
+var Gh=function(a){var Ab="local";return a};
+})(_yt_player);`)
+
+	start := len(`var _yt_player={};(function(g){var Ab=["}",3],Cd;
+function Ef(a){return a+Ab[0]}
+Cd=`)
+	fn, err := playerConfig(js).standaloneFunction(start)
+	require.NoError(t, err)
+
+	output, err := evalJavascript(context.Background(), fn, "x")
+	require.NoError(t, err)
+	assert.Equal(t, "x}3", output)
+}
+
+func TestExtractedFunctions(t *testing.T) {
+	extractions := 0
+	extract := func() (string, error) {
+		extractions++
+		return "function(a){return a}", nil
+	}
+
+	var cache playerCache
+	functions := cache.Set("/s/player/one/base.js", playerConfig("player one"))
+	for i := 0; i < 3; i++ {
+		_, cached := cache.Get("/s/player/one/base.js")
+		require.Same(t, functions, cached)
+		fn, err := cached.get("n", extract)
+		require.NoError(t, err)
+		assert.Equal(t, "function(a){return a}", fn)
+	}
+	assert.Equal(t, 1, extractions, "a player must only be scanned once")
+
+	// another function of the same player
+	_, err := functions.get("signature", extract)
+	require.NoError(t, err)
+	assert.Equal(t, 2, extractions)
+
+	// the functions go with the player they were extracted from
+	other := cache.Set("/s/player/two/base.js", playerConfig("player two"))
+	_, err = other.get("n", extract)
+	require.NoError(t, err)
+	assert.Equal(t, 3, extractions)
+
+	// without a cache every call extracts
+	var uncached *extractedFunctions
+	_, err = uncached.get("n", extract)
+	require.NoError(t, err)
+	assert.Equal(t, 4, extractions)
+}
@@ -0,0 +1,103 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yigitcilce/youtube/youtubetest"
+)
+
+func TestClient_GetWatchNext(t *testing.T) {
+	c, fake, _ := newFakeClient(t)
+	fake.AddVideo(youtubetest.Video{
+		ID:          "aaaaaaaaaaa",
+		Title:       "Gopher",
+		Author:      "gophers",
+		Likes:       1234,
+		Subscribers: 56700,
+		Chapters: []youtubetest.Chapter{
+			{Title: "Intro", Start: 0},
+			{Title: "Channels", Start: 90 * time.Second},
+		},
+	})
+	fake.AddPlaylist(youtubetest.Playlist{
+		ID:       "PLgophers",
+		Title:    "Gophers",
+		Author:   "gophers",
+		VideoIDs: []string{"BaW_jenozKc", "aaaaaaaaaaa", "5qap5aO4i9A"},
+	})
+	ctx := context.Background()
+
+	next, err := c.GetWatchNext(ctx, "aaaaaaaaaaa")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1234), next.Likes)
+	assert.Equal(t, int64(56700), next.Subscribers)
+	assert.Equal(t, []Chapter{{Title: "Intro", Start: 0}, {Title: "Channels", Start: 90 * time.Second}}, next.Chapters)
+	assert.Nil(t, next.Playlist)
+
+	require.Len(t, next.Related, 2)
+	assert.Equal(t, CompactVideo{
+		ID:        "5qap5aO4i9A",
+		Title:     "ciphered",
+		ChannelID: "UC5qap5aO4i9A",
+		Views:     42,
+		Published: "1 day ago",
+	}, next.Related[0])
+	assert.Equal(t, "BaW_jenozKc", next.Related[1].ID)
+	assert.Equal(t, 10*time.Second, next.Related[1].Duration)
+
+	next, err = c.GetWatchNext(ctx, "https://www.youtube.com/watch?v=aaaaaaaaaaa&list=PLgophers&index=2")
+	require.NoError(t, err)
+	require.NotNil(t, next.Playlist)
+	assert.Equal(t, "PLgophers", next.Playlist.ID)
+	assert.Equal(t, "Gophers", next.Playlist.Title)
+	assert.Equal(t, "gophers", next.Playlist.Author)
+	assert.Equal(t, 2, next.Playlist.Index)
+	assert.Equal(t, 3, next.Playlist.Total)
+	require.Len(t, next.Playlist.Videos, 3)
+	assert.Equal(t, "plain", next.Playlist.Videos[0].Title)
+
+	_, err = c.GetWatchNext(ctx, "https://www.youtube.com/playlist?list=PLgophers")
+	assert.ErrorIs(t, err, ErrUnsupportedURL)
+}
+
+func TestClient_GetVideo_WatchNext(t *testing.T) {
+	c, fake, _ := newFakeClient(t)
+	ctx := context.Background()
+
+	video, err := c.GetVideoContext(ctx, "BaW_jenozKc")
+	require.NoError(t, err)
+	assert.Nil(t, video.WatchNext)
+	assert.Equal(t, 0, fake.Requests("next"))
+
+	c.WatchNext = true
+	video, err = c.GetVideoContext(ctx, "BaW_jenozKc")
+	require.NoError(t, err)
+	require.NotNil(t, video.WatchNext)
+	assert.Equal(t, "plain", video.Title)
+	require.Len(t, video.WatchNext.Related, 1)
+	assert.Equal(t, "5qap5aO4i9A", video.WatchNext.Related[0].ID)
+	assert.NoError(t, video.WatchNextErr)
+	assert.Equal(t, 1, fake.Requests("next"))
+
+	// a failed watch page leaves the video usable
+	failure := errors.New("next is down")
+	c.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if strings.HasSuffix(req.URL.Path, "/next") {
+			return nil, failure
+		}
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+	video, err = c.GetVideoContext(ctx, "BaW_jenozKc")
+	require.NoError(t, err)
+	assert.Equal(t, "plain", video.Title)
+	assert.Nil(t, video.WatchNext)
+	assert.ErrorIs(t, video.WatchNextErr, failure)
+}
@@ -0,0 +1,107 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressTracker(t *testing.T) {
+	var reports []Progress
+	c := &Client{OnProgress: func(p Progress) { reports = append(reports, p) }}
+
+	tracker := c.newProgressTracker(&Video{ID: "BaW_jenozKc"}, &Format{ItagNo: 18}, 2*chunkSize+100)
+	require.NotNil(t, tracker)
+	clock := time.Unix(1000, 0)
+	tracker.now = func() time.Time { return clock }
+	tracker.lastReport = clock
+
+	// nothing is reported before the interval passed
+	tracker.startChunk(0)
+	tracker.add(1000)
+	assert.Empty(t, reports)
+
+	clock = clock.Add(time.Second)
+	tracker.add(1000)
+	require.Len(t, reports, 1)
+	p := reports[0]
+	assert.Equal(t, "BaW_jenozKc", p.VideoID)
+	assert.Equal(t, 18, p.ItagNo)
+	assert.Equal(t, int64(2000), p.Downloaded)
+	assert.Equal(t, 3, p.Chunks)
+	assert.Equal(t, 0, p.Chunk)
+	assert.Equal(t, 2000.0, p.Speed)
+	assert.Equal(t, 2000.0, p.AverageSpeed)
+	assert.Equal(t, time.Duration(float64(2*chunkSize+100-2000)/2000*float64(time.Second)), p.ETA)
+
+	// the average follows the speed smoothly
+	clock = clock.Add(time.Second)
+	tracker.startChunk(2 * chunkSize)
+	tracker.add(4000)
+	require.Len(t, reports, 2)
+	p = reports[1]
+	assert.Equal(t, 2, p.Chunk)
+	assert.Equal(t, 4000.0, p.Speed)
+	assert.InDelta(t, 2000+progressSmoothing*2000, p.AverageSpeed, 1e-9)
+
+	// a chunk started again, e.g. by a slower worker, becomes the current one
+	clock = clock.Add(time.Second)
+	tracker.startChunk(chunkSize)
+	tracker.add(0)
+	require.Len(t, reports, 3)
+	assert.Equal(t, 1, reports[2].Chunk)
+	assert.Equal(t, 0.0, reports[2].Speed)
+	assert.False(t, reports[2].Done)
+
+	clock = clock.Add(time.Second)
+	tracker.finish(nil)
+	require.Len(t, reports, 4)
+	p = reports[3]
+	assert.True(t, p.Done)
+	assert.NoError(t, p.Err)
+	assert.Zero(t, p.ETA)
+	assert.Equal(t, int64(6000), p.Downloaded)
+}
+
+func TestProgressTracker_Disabled(t *testing.T) {
+	tracker := (&Client{}).newProgressTracker(&Video{}, &Format{}, 100)
+	assert.Nil(t, tracker)
+
+	// a nil tracker does nothing
+	tracker.startChunk(0)
+	tracker.add(10)
+	tracker.finish(nil)
+	r := bytes.NewReader(nil)
+	assert.Same(t, r, tracker.reader(r))
+}
+
+func TestClient_DownloadTo_ProgressError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var reports []Progress
+	c := &Client{OnProgress: func(p Progress) {
+		mu.Lock()
+		reports = append(reports, p)
+		mu.Unlock()
+	}}
+
+	// the last report tells that the download failed
+	format := &Format{URL: srv.URL, ContentLength: 1000}
+	err := c.DownloadTo(context.Background(), &Video{}, format, &memoryWriterAt{data: make([]byte, 1000)})
+	require.Error(t, err)
+	require.NotEmpty(t, reports)
+	last := reports[len(reports)-1]
+	assert.True(t, last.Done)
+	assert.Equal(t, err, last.Err)
+}
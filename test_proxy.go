@@ -0,0 +1,45 @@
+package youtube
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProxyPool(t *testing.T) {
+	_, err := NewProxyPool()
+	assert.ErrorIs(t, err, ErrNoProxies)
+
+	_, err = NewProxyPool("ftp://proxy.local:21")
+	assert.ErrorIs(t, err, ErrUnsupportedProxyScheme)
+
+	pool, err := NewProxyPool("http://proxy.local:3128", "socks5://127.0.0.1:1080")
+	require.NoError(t, err)
+	assert.Equal(t, 2, pool.Len())
+}
+
+func TestProxyPool_Health(t *testing.T) {
+	pool, err := NewProxyPool("http://a.local", "http://b.local")
+	require.NoError(t, err)
+	pool.MaxFailures = 2
+
+	first := pool.pick()
+	assert.Equal(t, "a.local", first.status.URL.Host)
+
+	// a failure switches to the next proxy
+	pool.report(first, errors.New("connection refused"))
+	second := pool.pick()
+	assert.Equal(t, "b.local", second.status.URL.Host)
+
+	// the first proxy gets disabled after MaxFailures in a row
+	pool.report(first, errors.New("connection refused"))
+	status := pool.Status()
+	assert.False(t, status[0].Healthy())
+	assert.True(t, status[1].Healthy())
+	assert.Equal(t, 2, status[0].Failures)
+
+	pool.Rotate()
+	assert.Equal(t, "b.local", pool.pick().status.URL.Host)
+}
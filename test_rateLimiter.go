@@ -0,0 +1,64 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter_Unlimited(t *testing.T) {
+	limiter := NewRateLimiter(0)
+
+	start := time.Now()
+	require.NoError(t, limiter.WaitN(context.Background(), 1<<30))
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestRateLimiter_Limit(t *testing.T) {
+	c := Client{RateLimit: NewRateLimiter(100 << 10)}
+	data := make([]byte, 150<<10)
+
+	// first 100K come from the full bucket, the remaining 50K take half a second
+	start := time.Now()
+	n, err := io.Copy(io.Discard, c.limitReader(context.Background(), bytes.NewReader(data), nil))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), n)
+	assert.InDelta(t, 500*time.Millisecond, time.Since(start), float64(200*time.Millisecond))
+}
+
+func TestRateLimiter_Cancel(t *testing.T) {
+	limiter := NewRateLimiter(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, limiter.WaitN(ctx, 100), context.DeadlineExceeded)
+}
+
+func TestRateLimiter_DownloadLimitSetWhileStreaming(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 4<<10)
+	srv := newRangeServer(content)
+	defer srv.Close()
+
+	c := &Client{RateLimit: NewRateLimiter(10 << 20)}
+	s, err := c.OpenStream(context.Background(), &Video{}, &Format{URL: srv.URL, ContentLength: int64(len(content))})
+	require.NoError(t, err)
+	defer s.Close()
+
+	head := make([]byte, 10<<10)
+	_, err = io.ReadFull(s, head)
+	require.NoError(t, err)
+
+	// the first 20K of the rest come from the full bucket, the remaining 10K take half a second
+	c.DownloadRateLimit = NewRateLimiter(20 << 10)
+	start := time.Now()
+	rest, err := io.ReadAll(s)
+	require.NoError(t, err)
+	assert.Equal(t, content, append(head, rest...))
+	assert.InDelta(t, 500*time.Millisecond, time.Since(start), float64(200*time.Millisecond))
+}
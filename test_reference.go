@@ -0,0 +1,78 @@
+package youtube
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want Reference
+	}{
+		{"BaW_jenozKc", Reference{Kind: ReferenceVideo, VideoID: "BaW_jenozKc"}},
+		{"https://www.youtube.com/watch?v=BaW_jenozKc", Reference{Kind: ReferenceVideo, VideoID: "BaW_jenozKc"}},
+		{"youtube.com/watch?v=BaW_jenozKc&t=1m30s", Reference{Kind: ReferenceVideo, VideoID: "BaW_jenozKc", Start: 90 * time.Second}},
+		{"https://m.youtube.com/watch?v=BaW_jenozKc&list=PLwiyx1dc3P2JR9N8gQaQN_BCvlSlap7re&index=3", Reference{Kind: ReferenceVideo, VideoID: "BaW_jenozKc", PlaylistID: "PLwiyx1dc3P2JR9N8gQaQN_BCvlSlap7re", Index: 3}},
+		{"https://music.youtube.com/watch?v=BaW_jenozKc&feature=share", Reference{Kind: ReferenceVideo, VideoID: "BaW_jenozKc"}},
+		{"https://www.youtube.com/watch?v=BaW_jenozKc#t=1h2m3s", Reference{Kind: ReferenceVideo, VideoID: "BaW_jenozKc", Start: time.Hour + 2*time.Minute + 3*time.Second}},
+		{"https://youtu.be/BaW_jenozKc?t=42", Reference{Kind: ReferenceVideo, VideoID: "BaW_jenozKc", Start: 42 * time.Second}},
+		{"http://youtu.be/BaW_jenozKc/", Reference{Kind: ReferenceVideo, VideoID: "BaW_jenozKc"}},
+		{"https://www.youtube-nocookie.com/embed/BaW_jenozKc?start=10", Reference{Kind: ReferenceVideo, VideoID: "BaW_jenozKc", Start: 10 * time.Second}},
+		{"https://www.youtube.com/embed/videoseries?list=PLwiyx1dc3P2JR9N8gQaQN_BCvlSlap7re", Reference{Kind: ReferencePlaylist, PlaylistID: "PLwiyx1dc3P2JR9N8gQaQN_BCvlSlap7re"}},
+		{"https://www.youtube.com/v/BaW_jenozKc", Reference{Kind: ReferenceVideo, VideoID: "BaW_jenozKc"}},
+		{"https://www.youtube.com/shorts/BaW_jenozKc", Reference{Kind: ReferenceShort, VideoID: "BaW_jenozKc"}},
+		{"https://www.youtube.com/live/BaW_jenozKc?feature=share", Reference{Kind: ReferenceLive, VideoID: "BaW_jenozKc"}},
+		{"https://www.youtube.com/playlist?list=PLwiyx1dc3P2JR9N8gQaQN_BCvlSlap7re", Reference{Kind: ReferencePlaylist, PlaylistID: "PLwiyx1dc3P2JR9N8gQaQN_BCvlSlap7re"}},
+		{"https://www.youtube.com/channel/UCuAXFkgsw1L7xaCfnd5JJOw/videos", Reference{Kind: ReferenceChannel, ChannelID: "UCuAXFkgsw1L7xaCfnd5JJOw"}},
+		{"https://www.youtube.com/c/Kurzgesagt", Reference{Kind: ReferenceChannel, ChannelName: "Kurzgesagt"}},
+		{"https://www.youtube.com/@kurzgesagt/videos", Reference{Kind: ReferenceHandle, Handle: "kurzgesagt"}},
+		{"https://www.youtube.com/clip/UgkxU2HSeGL_NvmDJ-nQJrlLwllwMDBdGZFs", Reference{Kind: ReferenceClip, ClipID: "UgkxU2HSeGL_NvmDJ-nQJrlLwllwMDBdGZFs"}},
+		{"https://www.youtube.com/attribution_link?a=JdfC0C9V6ZI&u=%2Fwatch%3Fv%3DBaW_jenozKc%26feature%3Dshare", Reference{Kind: ReferenceVideo, VideoID: "BaW_jenozKc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			ref, err := ParseURL(tt.url)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *ref)
+		})
+	}
+}
+
+func TestParseURL_Invalid(t *testing.T) {
+	tests := []struct {
+		url  string
+		want error
+	}{
+		{"BaW_jenozK", ErrVideoIDMinLength},
+		{"BaW_jenozKcc", ErrVideoIDMinLength},
+		{"BaW_jen%zKc", ErrInvalidCharactersInVideoID},
+		{"https://www.youtube.com/watch?v=I8oGsuQ", ErrVideoIDMinLength},
+		{"https://www.youtube.com/watch?v=BaW_jen.zKc", ErrInvalidCharactersInVideoID},
+		{"https://www.youtube.com/watch?v=BaW_jenozKc&t=soon", ErrUnsupportedURL},
+		{"https://www.youtube.com/watch?v=BaW_jenozKc&index=0", ErrUnsupportedURL},
+		{"https://www.youtube.com/playlist", ErrUnsupportedURL},
+		{"https://www.youtube.com/feed/trending", ErrUnsupportedURL},
+		{"https://example.com/watch?v=BaW_jenozKc", ErrUnsupportedURL},
+		{"ftp://youtube.com/watch?v=BaW_jenozKc", ErrUnsupportedURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			_, err := ParseURL(tt.url)
+			assert.True(t, errors.Is(err, tt.want), "got %v", err)
+		})
+	}
+}
+
+func TestExtractVideoID_NoVideo(t *testing.T) {
+	_, err := ExtractVideoID("https://www.youtube.com/playlist?list=PLwiyx1dc3P2JR9N8gQaQN_BCvlSlap7re")
+	assert.True(t, errors.Is(err, ErrUnsupportedURL), err)
+
+	id, err := ExtractVideoID("https://youtu.be/BaW_jenozKc")
+	require.NoError(t, err)
+	assert.Equal(t, "BaW_jenozKc", id)
+}
@@ -0,0 +1,28 @@
+package youtube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat_ContainerCodecs(t *testing.T) {
+	tests := []struct {
+		mimeType  string
+		container string
+		codecs    []string
+	}{
+		{mimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`, container: "mp4", codecs: []string{"avc1.42001E", "mp4a.40.2"}},
+		{mimeType: `audio/webm; codecs="opus"`, container: "webm", codecs: []string{"opus"}},
+		{mimeType: `video/3gpp`, container: "3gpp"},
+		{mimeType: `video/mp4; codecs="avc1`},
+		{mimeType: ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mimeType, func(t *testing.T) {
+			f := &Format{MimeType: tt.mimeType}
+			assert.Equal(t, tt.container, f.Container())
+			assert.Equal(t, tt.codecs, f.Codecs())
+		})
+	}
+}
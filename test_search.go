@@ -0,0 +1,109 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yigitcilce/youtube/youtubetest"
+)
+
+func TestSearchOptions_Params(t *testing.T) {
+	tests := []struct {
+		name string
+		opts SearchOptions
+		want string
+	}{
+		{name: "none", opts: SearchOptions{}, want: ""},
+		{name: "videos", opts: SearchOptions{Type: SearchTypeVideo}, want: "EgIQAQ%3D%3D"},
+		{name: "playlists", opts: SearchOptions{Type: SearchTypePlaylist}, want: "EgIQAw%3D%3D"},
+		{name: "by upload date", opts: SearchOptions{Sort: SearchSortUploadDate}, want: "CAI%3D"},
+		{name: "today", opts: SearchOptions{UploadDate: SearchUploadToday}, want: "EgIIAg%3D%3D"},
+		{name: "short", opts: SearchOptions{Duration: SearchDurationShort}, want: "EgIYAQ%3D%3D"},
+		{name: "subtitles", opts: SearchOptions{Features: []SearchFeature{SearchFeatureSubtitles}}, want: "EgIoAQ%3D%3D"},
+		{name: "live", opts: SearchOptions{Features: []SearchFeature{SearchFeatureLive}}, want: "EgJAAQ%3D%3D"},
+		{name: "4k", opts: SearchOptions{Features: []SearchFeature{SearchFeature4K}}, want: "EgJwAQ%3D%3D"},
+		{name: "hd videos by views", opts: SearchOptions{Type: SearchTypeVideo, Features: []SearchFeature{SearchFeatureHD}, Sort: SearchSortViews}, want: "CAMSBBABIAE%3D"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := tt.opts.params()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, params)
+		})
+	}
+
+	_, err := (&SearchOptions{Type: "movie"}).params()
+	assert.Error(t, err)
+	_, err = (&SearchOptions{Features: []SearchFeature{"vr"}}).params()
+	assert.Error(t, err)
+}
+
+func TestClient_Search(t *testing.T) {
+	c, fake, _ := newFakeClient(t)
+	for _, id := range []string{"aaaaaaaaaaa", "bbbbbbbbbbb", "ccccccccccc"} {
+		fake.AddVideo(youtubetest.Video{ID: id, Title: "Gopher " + id, Author: "gophers", Duration: 3*time.Minute + 5*time.Second})
+	}
+	ctx := context.Background()
+
+	page, err := c.Search(ctx, "gopher", &SearchOptions{Type: SearchTypeVideo})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), page.EstimatedResults)
+	require.Len(t, page.Results, 2)
+	assert.Equal(t, SearchResult{
+		Kind:      ReferenceVideo,
+		ID:        "aaaaaaaaaaa",
+		Title:     "Gopher aaaaaaaaaaa",
+		Author:    "gophers",
+		ChannelID: "UCaaaaaaaaaaa",
+		Duration:  3*time.Minute + 5*time.Second,
+		Views:     42,
+		Published: "1 day ago",
+	}, page.Results[0])
+	require.NotEmpty(t, page.Continuation)
+
+	page, err = c.SearchNext(ctx, page)
+	require.NoError(t, err)
+	require.Len(t, page.Results, 1)
+	assert.Equal(t, "ccccccccccc", page.Results[0].ID)
+	assert.Empty(t, page.Continuation)
+
+	_, err = c.SearchNext(ctx, page)
+	assert.True(t, errors.Is(err, ErrNoMoreResults))
+	assert.Equal(t, 2, fake.Requests("search"))
+}
+
+func TestSearchResponse_Results(t *testing.T) {
+	body := `{"contents":{"twoColumnSearchResultsRenderer":{"primaryContents":{"sectionListRenderer":{"contents":[
+		{"itemSectionRenderer":{"contents":[
+			{"adSlotRenderer":{}},
+			{"channelRenderer":{"channelId":"UCuAXFkgsw1L7xaCfnd5JJOw","title":{"simpleText":"Kurzgesagt"},"videoCountText":{"runs":[{"text":"188"},{"text":" videos"}]}}},
+			{"playlistRenderer":{"playlistId":"PLFs4vir_WsTyXrrpFstD64Qj95vpy-yo1","title":{"simpleText":"Universe"},"videoCount":"1,234","shortBylineText":{"runs":[{"text":"Kurzgesagt","navigationEndpoint":{"browseEndpoint":{"browseId":"UCuAXFkgsw1L7xaCfnd5JJOw"}}}]}}},
+			{"videoRenderer":{"videoId":"BaW_jenozKc","title":{"runs":[{"text":"Live now"}]},"viewCountText":{"runs":[{"text":"1,024"},{"text":" watching"}]},"badges":[{"metadataBadgeRenderer":{"style":"BADGE_STYLE_TYPE_LIVE_NOW"}}]}}
+		]}}
+	]}}}}}`
+
+	var data searchResponseData
+	require.NoError(t, json.Unmarshal([]byte(body), &data))
+	results := data.results()
+
+	require.Len(t, results.Results, 3)
+	assert.Equal(t, SearchResult{Kind: ReferenceChannel, ID: "UCuAXFkgsw1L7xaCfnd5JJOw", Title: "Kurzgesagt", Author: "Kurzgesagt", ChannelID: "UCuAXFkgsw1L7xaCfnd5JJOw", VideoCount: 188}, results.Results[0])
+	assert.Equal(t, SearchResult{Kind: ReferencePlaylist, ID: "PLFs4vir_WsTyXrrpFstD64Qj95vpy-yo1", Title: "Universe", Author: "Kurzgesagt", ChannelID: "UCuAXFkgsw1L7xaCfnd5JJOw", VideoCount: 1234}, results.Results[1])
+	assert.True(t, results.Results[2].Live)
+	assert.Equal(t, int64(1024), results.Results[2].Views)
+	assert.Zero(t, results.Results[2].Duration)
+	assert.Empty(t, results.Continuation)
+}
+
+func TestParseCount(t *testing.T) {
+	assert.Equal(t, int64(1234567), parseCount("1,234,567 views"))
+	assert.Equal(t, int64(12345678901), parseCount("12,345,678,901 views"), "view counts exceed 32 bits")
+	assert.Zero(t, parseCount("No views"))
+	assert.Zero(t, parseCount(""))
+}
@@ -0,0 +1,153 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRangeServer(content []byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "stream", time.Time{}, bytes.NewReader(content))
+	}))
+}
+
+func TestParseContentRange(t *testing.T) {
+	start, end, total, err := parseContentRange("bytes 100-199/1000")
+	require.NoError(t, err)
+	assert.Equal(t, []int64{100, 199, 1000}, []int64{start, end, total})
+
+	_, _, _, err = parseContentRange("bytes */1000")
+	assert.Error(t, err)
+}
+
+func TestStream_Seek(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	srv := newRangeServer(content)
+	defer srv.Close()
+
+	c := &Client{}
+	size, err := c.streamSize(context.Background(), srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), size)
+
+	s := &Stream{client: c, ctx: context.Background(), url: srv.URL, size: size}
+	defer s.Close()
+
+	_, err = s.Seek(-5, io.SeekEnd)
+	require.NoError(t, err)
+	tail, err := io.ReadAll(s)
+	require.NoError(t, err)
+	assert.Equal(t, "fghij", string(tail))
+
+	_, err = s.Seek(2, io.SeekStart)
+	require.NoError(t, err)
+	buf := make([]byte, 3)
+	_, err = io.ReadFull(s, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "234", string(buf))
+}
+
+func TestClient_downloadRangeTo(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	srv := newRangeServer(content)
+	defer srv.Close()
+
+	out, err := os.CreateTemp(t.TempDir(), "stream")
+	require.NoError(t, err)
+	defer out.Close()
+
+	// write the second half first
+	c := &Client{}
+	require.NoError(t, c.downloadRangeTo(context.Background(), srv.URL, 10, 19, 20, out, nil, nil))
+	require.NoError(t, c.downloadRangeTo(context.Background(), srv.URL, 0, 9, 20, out, nil, nil))
+
+	written, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	assert.Equal(t, content, written)
+}
+
+// memoryWriterAt collects the chunks of DownloadTo, onWrite is called after every write
+type memoryWriterAt struct {
+	mu      sync.Mutex
+	data    []byte
+	offsets []int64
+	onWrite func(written int)
+	written int
+}
+
+func (m *memoryWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	m.mu.Lock()
+	copy(m.data[off:], p)
+	if off%chunkSize == 0 {
+		m.offsets = append(m.offsets, off)
+	}
+	m.written += len(p)
+	written := m.written
+	m.mu.Unlock()
+
+	if m.onWrite != nil {
+		m.onWrite(written)
+	}
+	return len(p), nil
+}
+
+func TestClient_DownloadTo_OutOfOrder(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), int(2*chunkSize+chunkSize/2)/10)
+	lastServed := make(chan struct{})
+
+	// the first chunk is held back until the last one is served, so the chunks arrive out of order
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.Header.Get("Range"), "bytes=0-") {
+			select {
+			case <-lastServed:
+			case <-time.After(5 * time.Second):
+			}
+		}
+		http.ServeContent(w, r, "stream", time.Time{}, bytes.NewReader(content))
+		if strings.HasPrefix(r.Header.Get("Range"), fmt.Sprintf("bytes=%d-", 2*chunkSize)) {
+			close(lastServed)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{DownloadWorkers: 3}
+	format := &Format{URL: srv.URL, ContentLength: int64(len(content))}
+	w := &memoryWriterAt{data: make([]byte, len(content))}
+
+	require.NoError(t, c.DownloadTo(context.Background(), &Video{}, format, w))
+	assert.True(t, bytes.Equal(content, w.data), "the chunks must be written at their offsets")
+	require.Len(t, w.offsets, 3)
+	assert.NotEqual(t, int64(0), w.offsets[0], "the first chunk was expected to arrive last")
+}
+
+func TestClient_DownloadTo_Canceled(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), int(2*chunkSize)/10)
+	srv := newRangeServer(content)
+	defer srv.Close()
+
+	// the caller gives up once the first chunk is complete, before the second one is handed out
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := &memoryWriterAt{data: make([]byte, len(content)), onWrite: func(written int) {
+		if int64(written) == chunkSize {
+			cancel()
+		}
+	}}
+
+	c := &Client{DownloadWorkers: 1}
+	format := &Format{URL: srv.URL, ContentLength: int64(len(content))}
+	err := c.DownloadTo(ctx, &Video{}, format, w)
+	assert.ErrorIs(t, err, context.Canceled)
+}
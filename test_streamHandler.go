@@ -0,0 +1,112 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamHandler(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+
+	// URLs of older generations are expired
+	var generation, resolutions int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("gen") != fmt.Sprint(atomic.LoadInt32(&generation)) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		http.ServeContent(w, r, "stream", time.Time{}, bytes.NewReader(content))
+	}))
+	defer upstream.Close()
+
+	h := NewStreamHandler(&Client{})
+	h.getVideo = func(ctx context.Context, id string) (*Video, error) {
+		atomic.AddInt32(&resolutions, 1)
+		url := fmt.Sprintf("%s/videoplayback?gen=%d", upstream.URL, atomic.LoadInt32(&generation))
+		return &Video{ID: id, Formats: FormatList{{ItagNo: 18, MimeType: "video/mp4", URL: url}}}, nil
+	}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	get := func(path, rangeHeader string) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		require.NoError(t, err)
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	// concurrent viewers share one resolution
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, body := get("/watch/BaW_jenozKc?itag=18", "")
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, string(content), body)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&resolutions))
+
+	resp, body := get("/watch/BaW_jenozKc?itag=18", "bytes=10-14")
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(t, "bytes 10-14/20", resp.Header.Get("Content-Range"))
+	assert.Equal(t, "abcde", body)
+
+	// an expired URL is resolved again transparently
+	atomic.AddInt32(&generation, 1)
+	resp, body = get("/watch/BaW_jenozKc?itag=18", "bytes=15-")
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(t, "fghij", body)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&resolutions))
+
+	resp, _ = get("/watch/BaW_jenozKc?itag=22", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = get("/watch/short", "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, _ = get("/other/BaW_jenozKc", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestStreamHandler_ZeroValue(t *testing.T) {
+	c, _, content := newFakeClient(t)
+
+	// without NewStreamHandler the client is asked for the video
+	srv := httptest.NewServer(&StreamHandler{Client: c})
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/watch/BaW_jenozKc?itag=18")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, content, body)
+}
+
+func TestStreamURLExpiry(t *testing.T) {
+	now := time.Unix(1000, 0)
+	assert.Equal(t, time.Unix(5000, 0).Add(-streamURLExpiryMargin), streamURLExpiry("https://host/videoplayback?expire=5000&itag=18", now))
+	assert.Equal(t, now.Add(defaultStreamURLLifetime), streamURLExpiry("https://host/videoplayback?itag=18", now))
+}
@@ -0,0 +1,57 @@
+package youtube
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestWithStandardThumbnails(t *testing.T) {
+	thumbnails := withStandardThumbnails(defaultThumbnailBaseURL, "BaW_jenozKc", []Thumbnail{
+		{URL: "https://i.ytimg.com/vi/BaW_jenozKc/hqdefault.jpg?sqp=abc", Width: 480, Height: 360},
+	})
+
+	require.Len(t, thumbnails, 5)
+	assert.Equal(t, "https://i.ytimg.com/vi/BaW_jenozKc/maxresdefault.jpg", thumbnails[0].URL)
+	assert.Equal(t, uint(120), thumbnails[4].Width)
+
+	high, ok := thumbnails.Size(ThumbnailHigh)
+	assert.True(t, ok)
+	assert.Equal(t, "https://i.ytimg.com/vi/BaW_jenozKc/hqdefault.jpg?sqp=abc", high.URL)
+}
+
+func TestClient_GetThumbnail(t *testing.T) {
+	var requested []string
+	c := &Client{HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requested = append(requested, req.URL.Path)
+
+		status, body := http.StatusNotFound, ""
+		if strings.HasSuffix(req.URL.Path, "/hqdefault.jpg") {
+			status, body = http.StatusOK, "jpeg"
+		}
+		return &http.Response{StatusCode: status, Status: http.StatusText(status), Body: io.NopCloser(strings.NewReader(body))}, nil
+	})}}
+
+	image, size, err := c.GetThumbnail(context.Background(), &Video{ID: "BaW_jenozKc"}, ThumbnailMaxRes)
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", string(image))
+	assert.Equal(t, ThumbnailHigh, size)
+	assert.Equal(t, []string{"/vi/BaW_jenozKc/maxresdefault.jpg", "/vi/BaW_jenozKc/sddefault.jpg", "/vi/BaW_jenozKc/hqdefault.jpg"}, requested)
+
+	_, _, err = c.GetThumbnail(context.Background(), &Video{ID: "BaW_jenozKc"}, ThumbnailMedium)
+	assert.EqualError(t, err, "no thumbnail found for video BaW_jenozKc")
+
+	_, _, err = c.GetThumbnail(context.Background(), &Video{ID: "BaW_jenozKc"}, "huge")
+	assert.Error(t, err)
+}
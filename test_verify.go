@@ -0,0 +1,57 @@
+package youtube
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSizeVerifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected int64
+		want     string
+		short    bool
+	}{
+		{name: "exact", body: "0123456789", expected: 10, want: "0123456789"},
+		{name: "short", body: "01234", expected: 10, want: "01234", short: true},
+		{name: "long", body: "0123456789abc", expected: 10, want: "0123456789"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &sizeVerifier{r: strings.NewReader(tt.body), offset: 100, expected: tt.expected}
+			got, err := io.ReadAll(v)
+			assert.Equal(t, tt.want, string(got))
+
+			if int64(len(tt.body)) == tt.expected {
+				assert.NoError(t, err)
+				return
+			}
+
+			var mismatch ErrSizeMismatch
+			if assert.True(t, errors.As(err, &mismatch)) {
+				assert.Equal(t, int64(100), mismatch.Offset)
+				assert.Equal(t, tt.short, mismatch.Short())
+			}
+		})
+	}
+}
+
+func TestVerifyContentRange(t *testing.T) {
+	resp := &http.Response{Header: http.Header{"Content-Range": {"bytes 10-19/100"}}}
+
+	length, err := verifyContentRange(resp, 10, 19, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), length)
+
+	_, err = verifyContentRange(resp, 0, 19, 100)
+	assert.ErrorIs(t, err, ErrContentRangeMismatch)
+
+	_, err = verifyContentRange(resp, 10, 19, 200)
+	assert.ErrorIs(t, err, ErrContentRangeMismatch)
+}
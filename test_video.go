@@ -0,0 +1,55 @@
+package youtube
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVideo_parseVideoInfo(t *testing.T) {
+	body := []byte(`{
+		"streamingData": {
+			"formats": [{"itag": 18, "url": "https://example.com/18", "mimeType": "video/mp4; codecs=\"avc1.42001E, mp4a.40.2\"", "contentLength": "1000"}],
+			"adaptiveFormats": [{"itag": 140, "signatureCipher": "s=abc&sp=sig&url=x", "mimeType": "audio/mp4; codecs=\"mp4a.40.2\"", "audioChannels": 2}]
+		},
+		"videoDetails": {
+			"title": "Gopher",
+			"author": "Go",
+			"channelId": "UCgophers",
+			"lengthSeconds": "125",
+			"viewCount": "3000000000",
+			"shortDescription": "about gophers"
+		},
+		"microformat": {"playerMicroformatRenderer": {"publishDate": "2021-06-17"}}
+	}`)
+
+	v := &Video{ID: "Jl8fV1jUQPs"}
+	require.NoError(t, v.parseVideoInfo(body))
+	assert.Equal(t, "Gopher", v.Title)
+	assert.Equal(t, "Go", v.Author)
+	assert.Equal(t, "UCgophers", v.ChannelID)
+	assert.Equal(t, "about gophers", v.Description)
+	assert.Equal(t, 125*time.Second, v.Duration)
+	assert.Equal(t, int64(3000000000), v.Views, "view counts exceed 32 bits")
+	assert.Equal(t, time.Date(2021, 6, 17, 0, 0, 0, 0, time.UTC), v.PublishDate)
+
+	require.Len(t, v.Formats, 2)
+	assert.Equal(t, int64(1000), v.Formats[0].ContentLength)
+	assert.False(t, v.Formats[0].IsCiphered())
+	assert.True(t, v.Formats[1].IsCiphered())
+	assert.Equal(t, 2, v.Formats[1].AudioChannels)
+}
+
+func TestVideo_parseVideoInfo_Missing(t *testing.T) {
+	// unknown values are left empty
+	v := &Video{}
+	require.NoError(t, v.parseVideoInfo([]byte(`{"streamingData": {"formats": [{"itag": 18}]}, "videoDetails": {"viewCount": "many"}}`)))
+	assert.Zero(t, v.Views)
+	assert.Zero(t, v.Duration)
+	assert.True(t, v.PublishDate.IsZero())
+
+	assert.Error(t, v.parseVideoInfo([]byte(`{"videoDetails": {"title": "no formats"}}`)))
+	assert.Error(t, v.parseVideoInfo([]byte(`not json`)))
+}
@@ -0,0 +1,61 @@
+// Writes the cases.json of a player by running its whole base.js in node, so that the expected
+// results don't depend on the extraction of the Go code:
+//
+//   node testdata/players/cases.js testdata/players/<player> <signature function> <n-function>
+//
+// The names are those of the functions inside the player closure, e.g. found in the browser debugger.
+"use strict";
+
+const fs = require("fs");
+const path = require("path");
+const vm = require("vm");
+
+const [dir, signatureName, nName] = process.argv.slice(2);
+if (!dir || !signatureName || !nName) {
+  console.error("usage: node cases.js <player dir> <signature function> <n-function>");
+  process.exit(2);
+}
+
+const signatureInputs = [
+  "AOq0QJ8wRQIhAJ9ynEFd0JVoL3hK0Ff0ZQ5QZb4u8T3S3TJ9yVT3gjWfAiBW3Vv6RyRv6kS9R5HOVGq7aT3oKg7MGqH2ZZbcd8Dq-w==",
+  "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+];
+const nInputs = ["5JwZC2cDDfw7vWu", "kTNkA1NbNxKZ0Bmx", "a1b2c3"];
+
+// the functions are handed out at the end of the player closure
+const js = fs.readFileSync(path.join(dir, "base.js"), "utf8");
+const end = js.lastIndexOf("})(_yt_player);");
+if (end < 0) {
+  console.error("the player closure })(_yt_player); was not found");
+  process.exit(1);
+}
+const exported = js.slice(0, end) + `;__export(${signatureName},${nName});` + js.slice(end);
+
+// browser objects the player touches while loading, every property of them is a callable stub
+function stub() {
+  return new Proxy(function () {}, {
+    get: (target, key) => (key === Symbol.toPrimitive ? () => "" : stub()),
+    apply: () => stub(),
+    construct: () => stub(),
+  });
+}
+
+let signature, n;
+const sandbox = {
+  __export: (s, f) => {
+    signature = s;
+    n = f;
+  },
+};
+for (const name of ["document", "navigator", "location", "localStorage", "sessionStorage", "performance", "XMLHttpRequest"]) {
+  sandbox[name] = stub();
+}
+sandbox.window = sandbox;
+sandbox.self = sandbox;
+vm.runInNewContext(exported, sandbox, { filename: "base.js" });
+
+const cases = {
+  n: nInputs.map((input) => ({ input, output: n(input) })),
+  signature: signatureInputs.map((input) => ({ input, output: signature(input) })),
+};
+fs.writeFileSync(path.join(dir, "cases.json"), JSON.stringify(cases, null, 2) + "\n");
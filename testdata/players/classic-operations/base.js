@@ -0,0 +1,13 @@
+var _yt_player={};(function(g){var window=this;/*
+ Copyright The Closure Library Authors.
+ SPDX-License-Identifier: Apache-2.0
+*/
+var ba,ca;ba=function(a){var b=0;return function(){return b<a.length?{done:!1,value:a[b++]}:{done:!0}}};
+var Ir={Vx:function(a,b){var c=a[0];a[0]=a[b%a.length];a[b%a.length]=c},
+NI:function(a){a.reverse()},
+q9:function(a,b){a.splice(0,b)}};
+Yqa=function(a){a=a.split("");Ir.q9(a,1);Ir.Vx(a,45);Ir.NI(a,35);Ir.Vx(a,3);Ir.q9(a,2);Ir.NI(a,70);return a.join("")};
+var Xma=function(a){var b=a.split(""),c=[1836461734,-406981637,function(d,e){d.push(e)},b,-1389519838,"length",function(d){d.reverse()}];for(var f=0;f<b.length;f++){var h=(f*7+3)%b.length,k=b[f];b[f]=b[h];b[h]=k}c[6](b);if(b.length>5){var l=b.splice(0,2);b.push(l[1],l[0])}return b.join("")};
+g.Pz=function(a){var b;a.set("alr","yes");a.j&&(b=a.get("n"))&&(b=Xma(b),a.set("n",b))};
+var ZK={sts:18800,version:"2021-06"},OK=function(){return{cver:"2.20210617.01.00",signatureTimestamp:18800}};
+})(_yt_player);
@@ -0,0 +1,11 @@
+var _yt_player={};(function(g){var window=this;
+var kb="}{",lb=/[{}]\//g,mb=`}${"{"}`;
+var P7={Tz:function(a,b){a.splice(0,b)},Mo:function(a){a.reverse()},Ke:function(a,b){var c=a[0];a[0]=a[b%a.length];a[b%a.length]=c}};
+var Yl=function(a){a=a.split("");P7.Ke(a,5);P7.Mo(a,1);P7.Tz(a,2);P7.Ke(a,61);return a.join("")};
+var Hx=["{",'}',"\"}\"","abcdefghijklmnopqrstuvwxyz0123456789-_",/\}/,`}${1+1}{`];
+function Jx(a,b){/* } */var c=b.indexOf(a);return c<0?a:b.charAt((c+7)%b.length)}
+var Kx=function(a){if(typeof Hx==="undefined")return a;var b=a.split(""),c=Hx[3],d="}";for(var e=0;e<b.length;e++){b[e]=Jx(b[e],c)}// {
+if(Hx[4].test(d)&&Hx[5]==="}2{")b.reverse();return b.join("").replace(/[{]/g,"")+d.replace(lb,"")};
+g.Qq=function(a){var b;a.set("alr","yes");a.url&&(b=a.get("n"))&&(b=Kx(b),a.set("n",b))};
+var Lx={ts:"2022-08"},Mx=function(){return{hl:"en",signatureTimestamp:19212}};
+})(_yt_player);
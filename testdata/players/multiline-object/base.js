@@ -0,0 +1,10 @@
+var _yt_player={};(function(g){var window=this;
+var R$={u2:function(a,b){a.splice(0,b)},
+HJ:function(a,b){var c=a[0];a[0]=a[b];a[b]=c},
+Xd:function(a){a.reverse()}
+};
+var Ola=function(a){a=a.split("");R$.HJ(a,9);R$.u2(a,3);R$.Xd(a,53);R$.HJ(a,21);return a.join("")};
+var Ay3=function(a){var b=a.split(""),c=[];for(var d=0;d<b.length;d++){var e=b[d].charCodeAt(0);if(e>=97&&e<=122){c.push(String.fromCharCode((e-97+13)%26+97))}else{c.push(b[d])}}return c.join("")};
+g.fA=function(a){var b;a.set("alr","yes");a.url&&(b=a.get("n"))&&(b=Ay3(b),a.set("n",b))};
+var Ula={version:"2022-02"},Vla=function(){return{ctx:"x",signatureTimestamp:19039}};
+})(_yt_player);
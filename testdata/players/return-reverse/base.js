@@ -0,0 +1,9 @@
+var _yt_player={};(function(g){var window=this;
+var Dl={cI:function(a){return a.reverse()},
+t4:function(a,b){var c=a[0];a[0]=a[b%a.length];a[b%a.length]=c;return a},
+Jo:function(a,b){a.splice(0,b)}};
+function eua(a){a=a.split("");a=Dl.t4(a,62);a=Dl.cI(a,11);Dl.Jo(a,3);a=Dl.t4(a,8);return a.join("")};
+var qva=function(a){var b=a.split(""),c=b.length,d={x:0};if(c){for(var e=0;e<c;e++){d.x=(d.x+b[e].charCodeAt(0))%64}b.push("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"[d.x])}return b.reverse().join("")};
+g.Wz=function(a){var b;a.set("alr","yes");a.url&&(b=a.get("n"))&&(b=qva(b),a.set("n",b))};
+var bla={clientName:"WEB",clientVersion:"2.20211118.00.00"},cla=function(){return{foo:1,signatureTimestamp:19010}};
+})(_yt_player);
@@ -0,0 +1,11 @@
+var _yt_player={};(function(g){var window=this;
+var Fu=function(a,b){return a+b},Gu="alr";
+var oD={yk:function(a,b){a.splice(0,b)},
+Wo:function(a){a.reverse()},
+Pq:function(a,b){for(var c=b%a.length;c>0;c--)a.push(a.shift())},
+sF:function(a,b){var c=a[0];a[0]=a[b%a.length];a[b%a.length]=c}};
+bAa=function(a){a=a.split("");oD.Pq(a,17);oD.sF(a,40);oD.Wo(a,6);oD.yk(a,3);oD.Pq(a,54);oD.sF(a,9);return a.join("")};
+var cAa=function(a){var b=a.split(""),c=b.length;if(c<2)return a;var d={x:b[0]};b[0]=b[c-1];b[c-1]=d.x;return b.join("")+"_"+c};
+g.Gd=function(a){var b;a.set(Gu,"yes");a.url&&(b=a.get("n"))&&(b=cAa(b),a.set("n",b))};
+var dAa={ts:"2022-05"},eAa=function(){return{hl:"en",signatureTimestamp:19128}};
+})(_yt_player);
@@ -0,0 +1,102 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ThumbnailSize names the thumbnails YouTube offers for every video
+type ThumbnailSize string
+
+const (
+	ThumbnailDefault  ThumbnailSize = "default"       // 120x90
+	ThumbnailMedium   ThumbnailSize = "mqdefault"     // 320x180
+	ThumbnailHigh     ThumbnailSize = "hqdefault"     // 480x360
+	ThumbnailStandard ThumbnailSize = "sddefault"     // 640x480
+	ThumbnailMaxRes   ThumbnailSize = "maxresdefault" // 1280x720, missing for some videos
+)
+
+// thumbnailSizes are ordered from the largest to the smallest
+var thumbnailSizes = []struct {
+	size          ThumbnailSize
+	width, height uint
+}{
+	{ThumbnailMaxRes, 1280, 720},
+	{ThumbnailStandard, 640, 480},
+	{ThumbnailHigh, 480, 360},
+	{ThumbnailMedium, 320, 180},
+	{ThumbnailDefault, 120, 90},
+}
+
+// thumbnailURLFormat is filled with the base URL, the video ID and the size
+const thumbnailURLFormat = "%s/vi/%s/%s.jpg"
+
+// Thumbnail is a preview image of a video
+type Thumbnail struct {
+	URL    string `json:"url"`
+	Width  uint   `json:"width"`
+	Height uint   `json:"height"`
+}
+
+// Thumbnails are the preview images of a video, ordered from the largest to the smallest
+type Thumbnails []Thumbnail
+
+// Size returns the thumbnail with the given name
+func (t Thumbnails) Size(size ThumbnailSize) (Thumbnail, bool) {
+	for _, thumbnail := range t {
+		if strings.Contains(thumbnail.URL, "/"+string(size)+".") {
+			return thumbnail, true
+		}
+	}
+	return Thumbnail{}, false
+}
+
+// withStandardThumbnails adds the thumbnails every video has at baseURL to the ones of the player response,
+// the player response often lacks maxresdefault
+func withStandardThumbnails(baseURL, videoID string, thumbnails []Thumbnail) Thumbnails {
+	all := append(Thumbnails{}, thumbnails...)
+	for _, s := range thumbnailSizes {
+		if _, ok := all.Size(s.size); !ok {
+			all = append(all, Thumbnail{
+				URL:    fmt.Sprintf(thumbnailURLFormat, baseURL, videoID, s.size),
+				Width:  s.width,
+				Height: s.height,
+			})
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Width*all[i].Height > all[j].Width*all[j].Height
+	})
+	return all
+}
+
+// GetThumbnail downloads the thumbnail of the given size as JPEG.
+// If it doesn't exist, the next smaller size is tried. The size of the returned image is returned as well.
+func (c *Client) GetThumbnail(ctx context.Context, video *Video, size ThumbnailSize) ([]byte, ThumbnailSize, error) {
+	start := -1
+	for i, s := range thumbnailSizes {
+		if s.size == size {
+			start = i
+		}
+	}
+	if start < 0 {
+		return nil, "", fmt.Errorf("unknown thumbnail size %q", size)
+	}
+
+	for _, s := range thumbnailSizes[start:] {
+		image, err := c.httpGetBodyBytes(ctx, fmt.Sprintf(thumbnailURLFormat, c.thumbnailBaseURL(), video.ID, s.size))
+
+		var status ErrUnexpectedHTTPStatusCode
+		if errors.As(err, &status) && status == http.StatusNotFound {
+			continue
+		}
+		return image, s.size, err
+	}
+
+	return nil, "", fmt.Errorf("no thumbnail found for video %s", video.ID)
+}
@@ -0,0 +1,55 @@
+package youtube
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// sizeVerifier reads exactly expected bytes from r, otherwise it returns ErrSizeMismatch
+type sizeVerifier struct {
+	r        io.Reader
+	offset   int64
+	expected int64
+	read     int64
+}
+
+func (v *sizeVerifier) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	v.read += int64(n)
+
+	// never hand out more than expected
+	if v.read > v.expected {
+		extra := int(v.read - v.expected)
+		return n - extra, v.mismatch()
+	}
+
+	if err == io.EOF && v.read < v.expected {
+		return n, v.mismatch()
+	}
+
+	return n, err
+}
+
+func (v *sizeVerifier) mismatch() ErrSizeMismatch {
+	return ErrSizeMismatch{Offset: v.offset, Expected: v.expected, Actual: v.read}
+}
+
+// verifyContentRange checks that a range response lies within start and end and belongs to a stream of size total.
+// It returns the length of the received range. A total of 0 is not checked.
+func verifyContentRange(resp *http.Response, start, end, total int64) (int64, error) {
+	header := resp.Header.Get("Content-Range")
+	rangeStart, rangeEnd, rangeTotal, err := parseContentRange(header)
+	if err != nil {
+		return 0, err
+	}
+
+	if rangeStart != start || rangeEnd > end || rangeEnd < rangeStart {
+		return 0, fmt.Errorf("%w: requested bytes %d-%d, got %q", ErrContentRangeMismatch, start, end, header)
+	}
+	if total > 0 && rangeTotal != total {
+		return 0, fmt.Errorf("%w: expected total size %d, got %q", ErrContentRangeMismatch, total, header)
+	}
+
+	return rangeEnd - rangeStart + 1, nil
+}
@@ -1,71 +1,80 @@
-package youtube
-
-import (
-	"encoding/json"
-	"errors"
-	"fmt"
-	"regexp"
-	"strings"
-	"time"
-)
-
-// For extracting VideoID
-var videoRegexpList = []*regexp.Regexp{
-	regexp.MustCompile(`(?:v|embed|shorts|watch\?v)(?:=|/)([^"&?/=%]{11})`),
-	regexp.MustCompile(`(?:=|/)([^"&?/=%]{11})`),
-	regexp.MustCompile(`([^"&?/=%]{11})`),
-}
-
-type Video struct {
-	ID          string
-	Title       string
-	Description string
-	Duration    time.Duration
-	PublishDate time.Time
-	Formats     FormatList
-}
-
-// parseVideoInfo parses video information from http response body
-func (v *Video) parseVideoInfo(body []byte) error {
-	var prData playerResponseData
-
-	err := json.Unmarshal(body, &prData)
-	if err != nil {
-		return fmt.Errorf("unable to parse player response JSON: %w", err)
-	}
-
-	return v.extractDataFromPlayerResponse(prData)
-}
-
-func (v *Video) extractDataFromPlayerResponse(prData playerResponseData) error {
-	// Get title for file creation
-	v.Title = prData.VideoDetails.Title
-
-	// Assign Streams for download process
-	v.Formats = append(prData.StreamingData.Formats, prData.StreamingData.AdaptiveFormats...)
-	if len(v.Formats) == 0 {
-		return errors.New("no formats found in the server's answer")
-	}
-	return nil
-}
-
-// ExtractVideoID extracts the videoID from the given string
-func ExtractVideoID(videoID string) (string, error) {
-	if strings.ContainsAny(videoID, "\"?&/<%=") {
-		for _, re := range videoRegexpList {
-			if isMatch := re.MatchString(videoID); isMatch {
-				subs := re.FindStringSubmatch(videoID)
-				videoID = subs[1]
-			}
-		}
-	}
-
-	if strings.ContainsAny(videoID, "?&/<%=") {
-		return "", ErrInvalidCharactersInVideoID
-	}
-	if len(videoID) < 10 {
-		return "", ErrVideoIDMinLength
-	}
-
-	return videoID, nil
-}
+package youtube
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+type Video struct {
+	ID          string
+	Title       string
+	Description string
+	Author      string
+	ChannelID   string
+	Views       int64
+	Duration    time.Duration
+	PublishDate time.Time
+	Formats     FormatList
+	Thumbnails  Thumbnails
+
+	// WatchNext is only fetched when Client.WatchNext is set,
+	// WatchNextErr tells why it's missing if that request failed
+	WatchNext    *WatchNext
+	WatchNextErr error `json:"-"`
+}
+
+// parseVideoInfo parses video information from http response body
+func (v *Video) parseVideoInfo(body []byte) error {
+	var prData playerResponseData
+
+	err := json.Unmarshal(body, &prData)
+	if err != nil {
+		return fmt.Errorf("unable to parse player response JSON: %w", err)
+	}
+
+	return v.extractDataFromPlayerResponse(prData)
+}
+
+func (v *Video) extractDataFromPlayerResponse(prData playerResponseData) error {
+	// Get title for file creation
+	v.Title = prData.VideoDetails.Title
+	v.Description = prData.VideoDetails.ShortDescription
+	v.Author = prData.VideoDetails.Author
+	v.ChannelID = prData.VideoDetails.ChannelID
+
+	if seconds, err := strconv.Atoi(prData.VideoDetails.LengthSeconds); err == nil {
+		v.Duration = time.Duration(seconds) * time.Second
+	}
+	if views, err := strconv.ParseInt(prData.VideoDetails.ViewCount, 10, 64); err == nil {
+		v.Views = views
+	}
+	if date, err := time.Parse("2006-01-02", prData.Microformat.PlayerMicroformatRenderer.PublishDate); err == nil {
+		v.PublishDate = date
+	}
+
+	// the client adds the standard sizes
+	v.Thumbnails = prData.VideoDetails.Thumbnail.Thumbnails
+
+	// Assign Streams for download process
+	v.Formats = append(prData.StreamingData.Formats, prData.StreamingData.AdaptiveFormats...)
+	if len(v.Formats) == 0 {
+		return errors.New("no formats found in the server's answer")
+	}
+	return nil
+}
+
+// ExtractVideoID extracts the videoID from a URL or checks the given ID, see ParseURL
+func ExtractVideoID(videoID string) (string, error) {
+	ref, err := ParseURL(videoID)
+	if err != nil {
+		return "", err
+	}
+	if ref.VideoID == "" {
+		return "", fmt.Errorf("%w: a %s has no video id", ErrUnsupportedURL, ref.Kind)
+	}
+
+	return ref.VideoID, nil
+}
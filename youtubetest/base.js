@@ -0,0 +1,9 @@
+var _yt_player={};(function(g){var window=this;
+var Zq={Ab:function(a,b){var c=a[0];a[0]=a[b%a.length];a[b%a.length]=c},
+Cd:function(a){a.reverse()},
+Ef:function(a,b){a.splice(0,b)}};
+var Gh=function(a){a=a.split("");Zq.Ab(a,7);Zq.Cd(a,45);Zq.Ef(a,2);Zq.Ab(a,30);return a.join("")};
+var Mn=function(a){var b=a.split(""),c=b.length;for(var d=0;d<c/2;d++){var e=b[d];b[d]=b[c-1-d];b[c-1-d]=e}return b.join("")};
+g.Vp=function(a){var b;a.set("alr","yes");a.url&&(b=a.get("n"))&&(b=Mn(b),a.set("n",b))};
+var Xs={sts:1,version:"fake"},Ys={clientName:"WEB",signatureTimestamp:19250};
+})(_yt_player);
@@ -0,0 +1,40 @@
+package youtubetest
+
+// signature is the signature a stream URL needs, as the player deciphers it
+func signature(videoID string) string {
+	return "SIG." + videoID + ".0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+}
+
+// scrambleSignature reverses the operations of the player function Gh:
+// swap 7, reverse, splice 2 and swap 30
+func scrambleSignature(plain string) string {
+	a := []byte(plain)
+	swap(a, 30)
+	a = append([]byte("xx"), a...)
+	reverse(a)
+	swap(a, 7)
+	return string(a)
+}
+
+func swap(a []byte, b int) {
+	pos := b % len(a)
+	a[0], a[pos] = a[pos], a[0]
+}
+
+func reverse(a []byte) {
+	for l, r := 0, len(a)-1; l < r; l, r = l+1, r-1 {
+		a[l], a[r] = a[r], a[l]
+	}
+}
+
+// throttledN is the n-parameter of the stream URLs in the player response
+func throttledN(videoID string) string {
+	return "n-" + videoID + "-throttled"
+}
+
+// decodedN is the n-parameter after the player function Mn, which reverses it
+func decodedN(videoID string) string {
+	a := []byte(throttledN(videoID))
+	reverse(a)
+	return string(a)
+}
@@ -0,0 +1,308 @@
+package youtubetest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxStreamBody is the number of bytes of audio and video responses a Recorder keeps
+const DefaultMaxStreamBody = 64 << 10
+
+// interaction is a recorded request and its response, the body is stored next to it
+type interaction struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	Range       string      `json:"range,omitempty"`
+	RequestBody string      `json:"requestBody,omitempty"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header"`
+	// Truncated is set if the body is only the start of the response
+	Truncated bool `json:"truncated,omitempty"`
+
+	body []byte
+}
+
+// key identifies requests, the query parameters are sorted
+func (i *interaction) key() string {
+	rawURL := i.URL
+	if u, err := url.Parse(i.URL); err == nil {
+		u.RawQuery = u.Query().Encode()
+		rawURL = u.String()
+	}
+
+	body := sha256.Sum256([]byte(i.RequestBody))
+	return fmt.Sprintf("%s %s %s %x", i.Method, rawURL, i.Range, body[:8])
+}
+
+// newInteraction reads the parts of the request that identify it, the body can be read again afterwards
+func newInteraction(req *http.Request) (*interaction, error) {
+	i := &interaction{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Range:  req.Header.Get("Range"),
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		i.RequestBody = string(body)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return i, nil
+}
+
+// Recorder is an http.RoundTripper writing all requests and responses into a fixture directory,
+// which a Replayer serves back. Audio and video bodies are truncated to MaxStreamBody,
+// the caller still receives the complete response.
+type Recorder struct {
+	// Transport sends the requests, defaults to http.DefaultTransport
+	Transport http.RoundTripper
+
+	// MaxStreamBody is the number of bytes of audio and video bodies that are recorded
+	MaxStreamBody int64
+
+	dir string
+	mu  sync.Mutex
+	seq int
+}
+
+// NewRecorder creates the fixture directory, existing fixtures in it are kept
+func NewRecorder(dir string, transport http.RoundTripper) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	existing, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Recorder{
+		Transport:     transport,
+		MaxStreamBody: DefaultMaxStreamBody,
+		dir:           dir,
+		seq:           len(existing),
+	}, nil
+}
+
+// RoundTrip sends the request and records it with its response
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	i, err := newInteraction(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	i.Status = resp.StatusCode
+	i.Header = resp.Header.Clone()
+	i.Header.Del("Set-Cookie")
+
+	if isStream(resp) {
+		// keep the start, the rest is passed through without recording it
+		var start []byte
+		start, err = io.ReadAll(io.LimitReader(resp.Body, r.MaxStreamBody+1))
+		i.body = start
+		if int64(len(start)) > r.MaxStreamBody {
+			i.body, i.Truncated = start[:r.MaxStreamBody], true
+		}
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(start), resp.Body), resp.Body}
+	} else {
+		i.body, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(i.body))
+	}
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	if err = r.save(i); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// save writes the interaction as NNNN.json with its body in NNNN.body
+func (r *Recorder) save(i *interaction) error {
+	r.mu.Lock()
+	r.seq++
+	name := filepath.Join(r.dir, fmt.Sprintf("%04d", r.seq))
+	r.mu.Unlock()
+
+	meta, err := json.MarshalIndent(i, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(name+".body", i.body, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(name+".json", meta, 0644)
+}
+
+// isStream tells audio and video responses apart from pages, scripts and API answers
+func isStream(resp *http.Response) bool {
+	contentType := resp.Header.Get("Content-Type")
+	return strings.HasPrefix(contentType, "video/") || strings.HasPrefix(contentType, "audio/") ||
+		strings.HasPrefix(resp.Request.URL.Path, "/videoplayback")
+}
+
+// Replayer is an http.RoundTripper answering requests from a fixture directory of a Recorder.
+// Requests are matched by method, URL, Range header and body. Repeated requests get the
+// recorded responses in order, the last one is repeated when they are used up.
+// Truncated streams are served as complete streams of the recorded length: their Content-Range
+// and the contentLength of the formats in recorded API answers are changed to match.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions map[string][]*interaction
+	used         map[string]int
+
+	// truncatedSizes maps the original size of truncated streams to the recorded size
+	truncatedSizes map[int64]int64
+}
+
+// contentLengthRegexp finds the sizes of formats in API answers
+var contentLengthRegexp = regexp.MustCompile(`"contentLength":\s*"(\d+)"`)
+
+// NewReplayer loads the fixtures of dir
+func NewReplayer(dir string) (*Replayer, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no fixtures in %s", dir)
+	}
+	sort.Strings(files)
+
+	r := &Replayer{
+		interactions:   make(map[string][]*interaction),
+		used:           make(map[string]int),
+		truncatedSizes: make(map[int64]int64),
+	}
+	for _, file := range files {
+		meta, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		i := &interaction{}
+		if err = json.Unmarshal(meta, i); err != nil {
+			return nil, fmt.Errorf("invalid fixture %s: %w", file, err)
+		}
+		if i.body, err = os.ReadFile(strings.TrimSuffix(file, ".json") + ".body"); err != nil {
+			return nil, err
+		}
+
+		key := i.key()
+		r.interactions[key] = append(r.interactions[key], i)
+
+		if start, total, ok := contentRange(i.Header); ok && i.Truncated && start == 0 {
+			r.truncatedSizes[total] = int64(len(i.body))
+		}
+	}
+	return r, nil
+}
+
+// contentRange returns the start and the total size of a "bytes 0-99/1000" header
+func contentRange(header http.Header) (start, total int64, ok bool) {
+	var end int64
+	_, err := fmt.Sscanf(header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &total)
+	return start, total, err == nil
+}
+
+// shortenFormats changes the sizes of formats whose streams were truncated to the recorded size
+func (r *Replayer) shortenFormats(body []byte) []byte {
+	if len(r.truncatedSizes) == 0 {
+		return body
+	}
+
+	return contentLengthRegexp.ReplaceAllFunc(body, func(match []byte) []byte {
+		size, _ := strconv.ParseInt(string(contentLengthRegexp.FindSubmatch(match)[1]), 10, 64)
+		if recorded, ok := r.truncatedSizes[size]; ok {
+			return []byte(fmt.Sprintf(`"contentLength":"%d"`, recorded))
+		}
+		return match
+	})
+}
+
+// RoundTrip answers with the recorded response, unknown requests fail
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	i, err := newInteraction(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	key := i.key()
+	r.mu.Lock()
+	recorded := r.interactions[key]
+	n := r.used[key]
+	if n < len(recorded)-1 {
+		r.used[key]++
+	}
+	r.mu.Unlock()
+
+	if len(recorded) == 0 {
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL)
+	}
+	found := recorded[n]
+
+	header := found.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	body := found.body
+	if found.Truncated {
+		// the announced length would wait for bytes that were never recorded
+		header.Set("Content-Length", fmt.Sprint(len(body)))
+		if start, _, ok := contentRange(header); ok {
+			end := start + int64(len(body))
+			header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end-1, end))
+		}
+	} else {
+		body = r.shortenFormats(body)
+		header.Set("Content-Length", fmt.Sprint(len(body)))
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", found.Status, http.StatusText(found.Status)),
+		StatusCode:    found.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,660 @@
+// Package youtubetest runs a fake YouTube for tests without network access.
+// It serves the embed page, a player with signature and n-parameter functions,
+// the innertube player, next and search API, Range capable stream URLs and thumbnails.
+// Point youtube.Client.BaseURL and ThumbnailBaseURL to Server.URL to use it.
+package youtubetest
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PlayerPath is the path of the fake player JavaScript
+const PlayerPath = "/s/player/fake0001/player_ias.vflset/en_US/base.js"
+
+// SignatureTimestamp is the signature timestamp of the fake player
+const SignatureTimestamp = 19250
+
+//go:embed base.js
+var playerJS []byte
+
+// Video is a video served by the fake
+type Video struct {
+	ID          string
+	Title       string
+	Author      string
+	Description string
+	Duration    time.Duration
+
+	// Content is the stream of every format of the video
+	Content []byte
+
+	// Ciphered serves the formats with a signatureCipher instead of a plain URL
+	Ciphered bool
+
+	// Comments are in top order, the newest order is the reverse. Without comments they are turned off.
+	Comments []Comment
+
+	Likes       int
+	Subscribers int
+	Chapters    []Chapter
+}
+
+// Chapter is a chapter of a video
+type Chapter struct {
+	Title string
+	Start time.Duration
+}
+
+// Playlist is a playlist of the fake, its videos are shown on the watch page when it's requested
+type Playlist struct {
+	ID       string
+	Title    string
+	Author   string
+	VideoIDs []string
+}
+
+// Comment is a comment of a video or a reply to one
+type Comment struct {
+	ID     string
+	Author string
+	Text   string
+	Likes  int
+	// Published is relative, like "2 days ago"
+	Published string
+	Pinned    bool
+	Hearted   bool
+	Replies   []Comment
+}
+
+// Formats of every video, a progressive MP4 and an audio-only M4A
+const (
+	ItagMP4 = 18
+	ItagM4A = 140
+)
+
+// Server is the fake YouTube
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	videos    map[string]Video
+	playlists map[string]Playlist
+	requests  map[string]int
+}
+
+// NewServer starts the fake with the videos, it must be closed at the end
+func NewServer(videos ...Video) *Server {
+	s := &Server{
+		videos:    make(map[string]Video),
+		playlists: make(map[string]Playlist),
+		requests:  make(map[string]int),
+	}
+	for _, v := range videos {
+		s.videos[v.ID] = v
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/embed/", s.count("embed", s.handleEmbed))
+	mux.HandleFunc(PlayerPath, s.count("player", s.handlePlayer))
+	mux.HandleFunc("/youtubei/v1/player", s.count("innertube", s.handleInnertube))
+	mux.HandleFunc("/youtubei/v1/search", s.count("search", s.handleSearch))
+	mux.HandleFunc("/youtubei/v1/next", s.count("next", s.handleNext))
+	mux.HandleFunc("/videoplayback", s.count("videoplayback", s.handleVideoplayback))
+	mux.HandleFunc("/vi/", s.count("thumbnail", s.handleThumbnail))
+
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// AddVideo adds or replaces a video
+func (s *Server) AddVideo(v Video) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.videos[v.ID] = v
+}
+
+// AddPlaylist adds or replaces a playlist
+func (s *Server) AddPlaylist(p Playlist) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.playlists[p.ID] = p
+}
+
+// Requests returns the number of requests of an endpoint:
+// embed, player, innertube, search, next, videoplayback or thumbnail
+func (s *Server) Requests(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[endpoint]
+}
+
+func (s *Server) count(endpoint string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[endpoint]++
+		s.mu.Unlock()
+
+		handler(w, r)
+	}
+}
+
+func (s *Server) video(id string) (Video, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.videos[id]
+	return v, ok
+}
+
+// handleEmbed serves an embed page referencing the player
+func (s *Server) handleEmbed(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, `<!DOCTYPE html><html><head><script>var ytcfg={"PLAYER_JS_URL":"%s"};</script></head><body></body></html>`, PlayerPath)
+}
+
+func (s *Server) handlePlayer(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/javascript")
+	w.Write(playerJS)
+}
+
+// handleInnertube answers the player API like YouTube, unknown videos are unplayable
+func (s *Server) handleInnertube(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Query().Get("key") == "" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		VideoID         string `json:"videoId"`
+		PlaybackContext struct {
+			ContentPlaybackContext struct {
+				SignatureTimestamp string `json:"signatureTimestamp"`
+			} `json:"contentPlaybackContext"`
+		} `json:"playbackContext"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.PlaybackContext.ContentPlaybackContext.SignatureTimestamp != strconv.Itoa(SignatureTimestamp) {
+		http.Error(w, "wrong signature timestamp", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	v, ok := s.video(req.VideoID)
+	if !ok {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"playabilityStatus": map[string]string{"status": "ERROR", "reason": "Video unavailable"},
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(s.playerResponse(v))
+}
+
+func (s *Server) playerResponse(v Video) map[string]interface{} {
+	format := func(itag int, mimeType, quality string) map[string]interface{} {
+		f := map[string]interface{}{
+			"itag":             itag,
+			"mimeType":         mimeType,
+			"quality":          quality,
+			"bitrate":          128000,
+			"contentLength":    strconv.Itoa(len(v.Content)),
+			"approxDurationMs": strconv.FormatInt(v.Duration.Milliseconds(), 10),
+		}
+		if itag == ItagMP4 {
+			f["width"], f["height"], f["fps"], f["qualityLabel"] = 640, 360, 30, "360p"
+		} else {
+			f["audioQuality"], f["audioSampleRate"], f["audioChannels"] = "AUDIO_QUALITY_MEDIUM", "44100", 2
+		}
+
+		// YouTube throttles stream URLs unless the n-parameter is decoded by the player
+		query := fmt.Sprintf("id=%s&itag=%d&n=%s", v.ID, itag, throttledN(v.ID))
+		if v.Ciphered {
+			f["signatureCipher"] = fmt.Sprintf("s=%s&sp=sig&url=%s", scrambleSignature(signature(v.ID)), url.QueryEscape(s.URL+"/videoplayback?"+query))
+		} else {
+			f["url"] = s.URL + "/videoplayback?" + query
+		}
+		return f
+	}
+
+	return map[string]interface{}{
+		"playabilityStatus": map[string]string{"status": "OK"},
+		"streamingData": map[string]interface{}{
+			"formats":         []interface{}{format(ItagMP4, `video/mp4; codecs="avc1.42001E, mp4a.40.2"`, "medium")},
+			"adaptiveFormats": []interface{}{format(ItagM4A, `audio/mp4; codecs="mp4a.40.2"`, "tiny")},
+		},
+		"videoDetails": map[string]interface{}{
+			"videoId":          v.ID,
+			"title":            v.Title,
+			"author":           v.Author,
+			"channelId":        "UC" + v.ID,
+			"lengthSeconds":    strconv.Itoa(int(v.Duration.Seconds())),
+			"viewCount":        "42",
+			"shortDescription": v.Description,
+		},
+	}
+}
+
+// SearchPageSize is the number of results on a page of the fake search
+const SearchPageSize = 2
+
+// handleSearch finds the videos with the query in their title, sorted by ID.
+// The continuation token is the query and the offset of the next page.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query        string `json:"query"`
+		Continuation string `json:"continuation"`
+	}
+	if r.Method != http.MethodPost || r.URL.Query().Get("key") == "" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query, offset := req.Query, 0
+	if req.Continuation != "" {
+		i := strings.LastIndexByte(req.Continuation, ':')
+		n, err := strconv.Atoi(req.Continuation[i+1:])
+		if i < 0 || err != nil {
+			http.Error(w, "invalid continuation", http.StatusBadRequest)
+			return
+		}
+		query, offset = req.Continuation[:i], n
+	}
+
+	s.mu.Lock()
+	var found []Video
+	for _, v := range s.videos {
+		if strings.Contains(strings.ToLower(v.Title), strings.ToLower(query)) {
+			found = append(found, v)
+		}
+	}
+	s.mu.Unlock()
+	sort.Slice(found, func(i, j int) bool { return found[i].ID < found[j].ID })
+
+	var items []interface{}
+	for i := offset; i < len(found) && i < offset+SearchPageSize; i++ {
+		items = append(items, videoRenderer(found[i]))
+	}
+	sections := []interface{}{map[string]interface{}{"itemSectionRenderer": map[string]interface{}{"contents": items}}}
+	if offset+SearchPageSize < len(found) {
+		sections = append(sections, map[string]interface{}{"continuationItemRenderer": map[string]interface{}{
+			"continuationEndpoint": map[string]interface{}{"continuationCommand": map[string]interface{}{
+				"token": fmt.Sprintf("%s:%d", query, offset+SearchPageSize),
+			}},
+		}})
+	}
+
+	resp := map[string]interface{}{"estimatedResults": strconv.Itoa(len(found))}
+	if req.Continuation == "" {
+		resp["contents"] = map[string]interface{}{"twoColumnSearchResultsRenderer": map[string]interface{}{
+			"primaryContents": map[string]interface{}{"sectionListRenderer": map[string]interface{}{"contents": sections}},
+		}}
+	} else {
+		resp["onResponseReceivedCommands"] = []interface{}{map[string]interface{}{
+			"appendContinuationItemsAction": map[string]interface{}{"continuationItems": sections},
+		}}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func videoRenderer(v Video) map[string]interface{} {
+	seconds := int(v.Duration.Seconds())
+	return map[string]interface{}{"videoRenderer": map[string]interface{}{
+		"videoId": v.ID,
+		"title":   map[string]interface{}{"runs": []interface{}{map[string]string{"text": v.Title}}},
+		"ownerText": map[string]interface{}{"runs": []interface{}{map[string]interface{}{
+			"text":               v.Author,
+			"navigationEndpoint": map[string]interface{}{"browseEndpoint": map[string]string{"browseId": "UC" + v.ID}},
+		}}},
+		"lengthText":        map[string]string{"simpleText": fmt.Sprintf("%d:%02d", seconds/60, seconds%60)},
+		"viewCountText":     map[string]string{"simpleText": "42 views"},
+		"publishedTimeText": map[string]string{"simpleText": "1 day ago"},
+	}}
+}
+
+// CommentPageSize is the number of comments or replies on a page of the fake
+const CommentPageSize = 2
+
+// handleNext answers the watch page of a video and the continuations of its comments.
+// The tokens are comments:{video}:{sort}:{offset} and replies:{video}:{comment}:{offset}.
+func (s *Server) handleNext(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		VideoID      string `json:"videoId"`
+		PlaylistID   string `json:"playlistId"`
+		Continuation string `json:"continuation"`
+	}
+	if r.Method != http.MethodPost || r.URL.Query().Get("key") == "" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var resp map[string]interface{}
+	if req.Continuation == "" {
+		v, ok := s.video(req.VideoID)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		resp = s.watchNextResponse(v, req.PlaylistID)
+	} else {
+		parts := strings.Split(req.Continuation, ":")
+		if len(parts) == 4 {
+			v, ok := s.video(parts[1])
+			offset, err := strconv.Atoi(parts[3])
+			switch {
+			case !ok || err != nil:
+			case parts[0] == "comments":
+				resp = commentsResponse(v, parts[2], offset)
+			case parts[0] == "replies":
+				resp = repliesResponse(v, parts[2], offset)
+			}
+		}
+		if resp == nil {
+			http.Error(w, "invalid continuation", http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// watchNextResponse answers the watch page with likes, subscribers and chapters of the video,
+// the other videos sorted by ID as related videos and the playlist if there is one
+func (s *Server) watchNextResponse(v Video, playlistID string) map[string]interface{} {
+	contents := []interface{}{
+		map[string]interface{}{"videoPrimaryInfoRenderer": map[string]interface{}{
+			"title": map[string]interface{}{"runs": []interface{}{map[string]string{"text": v.Title}}},
+			"videoActions": map[string]interface{}{"menuRenderer": map[string]interface{}{"topLevelButtons": []interface{}{
+				map[string]interface{}{"segmentedLikeDislikeButtonRenderer": map[string]interface{}{
+					"likeButton": map[string]interface{}{"toggleButtonRenderer": map[string]interface{}{
+						"defaultText": map[string]interface{}{
+							"simpleText": shortCount(v.Likes),
+							"accessibility": map[string]interface{}{"accessibilityData": map[string]string{
+								"label": fmt.Sprintf("%d likes", v.Likes),
+							}},
+						},
+					}},
+				}},
+			}}},
+		}},
+		map[string]interface{}{"videoSecondaryInfoRenderer": map[string]interface{}{
+			"owner": map[string]interface{}{"videoOwnerRenderer": map[string]interface{}{
+				"title":               map[string]interface{}{"runs": []interface{}{map[string]string{"text": v.Author}}},
+				"subscriberCountText": map[string]string{"simpleText": shortCount(v.Subscribers) + " subscribers"},
+			}},
+		}},
+	}
+	if len(v.Comments) > 0 {
+		contents = append(contents, map[string]interface{}{"itemSectionRenderer": map[string]interface{}{
+			"sectionIdentifier": "comment-item-section",
+			"contents":          []interface{}{continuationItem(fmt.Sprintf("comments:%s:top:0", v.ID))},
+		}})
+	}
+
+	s.mu.Lock()
+	var related []Video
+	for _, other := range s.videos {
+		if other.ID != v.ID {
+			related = append(related, other)
+		}
+	}
+	playlist, hasPlaylist := s.playlists[playlistID]
+	s.mu.Unlock()
+	sort.Slice(related, func(i, j int) bool { return related[i].ID < related[j].ID })
+
+	var secondary []interface{}
+	for _, other := range related {
+		secondary = append(secondary, map[string]interface{}{"compactVideoRenderer": compactVideo(other)})
+	}
+
+	results := map[string]interface{}{
+		"results":          map[string]interface{}{"results": map[string]interface{}{"contents": contents}},
+		"secondaryResults": map[string]interface{}{"secondaryResults": map[string]interface{}{"results": secondary}},
+	}
+	if hasPlaylist {
+		index := 0
+		var panel []interface{}
+		for i, id := range playlist.VideoIDs {
+			if id == v.ID {
+				index = i
+			}
+			entry, _ := s.video(id)
+			entry.ID = id
+			panel = append(panel, map[string]interface{}{"playlistPanelVideoRenderer": compactVideo(entry)})
+		}
+		results["playlist"] = map[string]interface{}{"playlist": map[string]interface{}{
+			"playlistId":   playlist.ID,
+			"title":        playlist.Title,
+			"ownerName":    map[string]string{"simpleText": playlist.Author},
+			"currentIndex": index,
+			"totalVideos":  len(playlist.VideoIDs),
+			"contents":     panel,
+		}}
+	}
+
+	var chapters []interface{}
+	for _, chapter := range v.Chapters {
+		chapters = append(chapters, map[string]interface{}{"chapterRenderer": map[string]interface{}{
+			"title":                map[string]string{"simpleText": chapter.Title},
+			"timeRangeStartMillis": chapter.Start.Milliseconds(),
+		}})
+	}
+
+	return map[string]interface{}{
+		"contents": map[string]interface{}{"twoColumnWatchNextResults": results},
+		"playerOverlays": map[string]interface{}{"playerOverlayRenderer": map[string]interface{}{
+			"decoratedPlayerBarRenderer": map[string]interface{}{"decoratedPlayerBarRenderer": map[string]interface{}{
+				"playerBar": map[string]interface{}{"multiMarkersPlayerBarRenderer": map[string]interface{}{
+					"markersMap": []interface{}{map[string]interface{}{
+						"key":   "DESCRIPTION_CHAPTERS",
+						"value": map[string]interface{}{"chapters": chapters},
+					}},
+				}},
+			}},
+		}},
+	}
+}
+
+func compactVideo(v Video) map[string]interface{} {
+	seconds := int(v.Duration.Seconds())
+	return map[string]interface{}{
+		"videoId": v.ID,
+		"title":   map[string]string{"simpleText": v.Title},
+		"longBylineText": map[string]interface{}{"runs": []interface{}{map[string]interface{}{
+			"text":               v.Author,
+			"navigationEndpoint": map[string]interface{}{"browseEndpoint": map[string]string{"browseId": "UC" + v.ID}},
+		}}},
+		"lengthText":        map[string]string{"simpleText": fmt.Sprintf("%d:%02d", seconds/60, seconds%60)},
+		"viewCountText":     map[string]string{"simpleText": "42 views"},
+		"publishedTimeText": map[string]string{"simpleText": "1 day ago"},
+	}
+}
+
+// commentsResponse answers a page of comments, the first page reloads the section with the sort menu
+func commentsResponse(v Video, order string, offset int) map[string]interface{} {
+	comments := append([]Comment(nil), v.Comments...)
+	switch order {
+	case "top":
+	case "newest":
+		for i, j := 0, len(comments)-1; i < j; i, j = i+1, j-1 {
+			comments[i], comments[j] = comments[j], comments[i]
+		}
+	default:
+		return nil
+	}
+
+	var items []interface{}
+	if offset == 0 {
+		var entries []interface{}
+		for _, sort := range []string{"top", "newest"} {
+			entries = append(entries, map[string]interface{}{
+				"title": sort,
+				"serviceEndpoint": map[string]interface{}{"continuationCommand": map[string]interface{}{
+					"token": fmt.Sprintf("comments:%s:%s:0", v.ID, sort),
+				}},
+			})
+		}
+		items = append(items, map[string]interface{}{"commentsHeaderRenderer": map[string]interface{}{
+			"sortMenu": map[string]interface{}{"sortFilterSubMenuRenderer": map[string]interface{}{"subMenuItems": entries}},
+		}})
+	}
+
+	for i := offset; i < len(comments) && i < offset+CommentPageSize; i++ {
+		c := comments[i]
+		thread := map[string]interface{}{"comment": map[string]interface{}{"commentRenderer": commentRenderer(c)}}
+		if len(c.Replies) > 0 {
+			thread["replies"] = map[string]interface{}{"commentRepliesRenderer": map[string]interface{}{
+				"contents": []interface{}{continuationItem(fmt.Sprintf("replies:%s:%s:0", v.ID, c.ID))},
+			}}
+		}
+		items = append(items, map[string]interface{}{"commentThreadRenderer": thread})
+	}
+	if offset+CommentPageSize < len(comments) {
+		items = append(items, continuationItem(fmt.Sprintf("comments:%s:%s:%d", v.ID, order, offset+CommentPageSize)))
+	}
+
+	action := "appendContinuationItemsAction"
+	if offset == 0 {
+		action = "reloadContinuationItemsCommand"
+	}
+	return map[string]interface{}{"onResponseReceivedEndpoints": []interface{}{map[string]interface{}{
+		action: map[string]interface{}{"continuationItems": items},
+	}}}
+}
+
+// repliesResponse answers a page of replies, more replies are behind a button like on YouTube
+func repliesResponse(v Video, commentID string, offset int) map[string]interface{} {
+	for _, c := range v.Comments {
+		if c.ID != commentID {
+			continue
+		}
+
+		var items []interface{}
+		for i := offset; i < len(c.Replies) && i < offset+CommentPageSize; i++ {
+			items = append(items, map[string]interface{}{"commentRenderer": commentRenderer(c.Replies[i])})
+		}
+		if offset+CommentPageSize < len(c.Replies) {
+			items = append(items, map[string]interface{}{"continuationItemRenderer": map[string]interface{}{
+				"button": map[string]interface{}{"buttonRenderer": map[string]interface{}{
+					"command": map[string]interface{}{"continuationCommand": map[string]interface{}{
+						"token": fmt.Sprintf("replies:%s:%s:%d", v.ID, c.ID, offset+CommentPageSize),
+					}},
+				}},
+			}})
+		}
+
+		return map[string]interface{}{"onResponseReceivedEndpoints": []interface{}{map[string]interface{}{
+			"appendContinuationItemsAction": map[string]interface{}{"continuationItems": items},
+		}}}
+	}
+	return nil
+}
+
+func commentRenderer(c Comment) map[string]interface{} {
+	r := map[string]interface{}{
+		"commentId":   c.ID,
+		"contentText": map[string]interface{}{"runs": []interface{}{map[string]string{"text": c.Text}}},
+		"authorText":  map[string]string{"simpleText": c.Author},
+		"authorEndpoint": map[string]interface{}{"browseEndpoint": map[string]string{
+			"browseId": "UC" + c.ID,
+		}},
+		"publishedTimeText": map[string]interface{}{"runs": []interface{}{map[string]string{"text": c.Published}}},
+		"replyCount":        len(c.Replies),
+		"actionButtons": map[string]interface{}{"commentActionButtonsRenderer": map[string]interface{}{
+			"creatorHeart": map[string]interface{}{"creatorHeartRenderer": map[string]bool{"isHearted": c.Hearted}},
+		}},
+	}
+	if c.Likes > 0 {
+		r["voteCount"] = map[string]string{"simpleText": shortCount(c.Likes)}
+	}
+	if c.Pinned {
+		r["pinnedCommentBadge"] = map[string]interface{}{"pinnedCommentBadgeRenderer": map[string]interface{}{}}
+	}
+	return r
+}
+
+// shortCount formats counts like YouTube, 1234 is 1.2K
+func shortCount(n int) string {
+	switch {
+	case n >= 1000000:
+		return strconv.FormatFloat(float64(n)/1e6, 'f', 1, 64) + "M"
+	case n >= 1000:
+		return strconv.FormatFloat(float64(n)/1e3, 'f', 1, 64) + "K"
+	}
+	return strconv.Itoa(n)
+}
+
+func continuationItem(token string) map[string]interface{} {
+	return map[string]interface{}{"continuationItemRenderer": map[string]interface{}{
+		"continuationEndpoint": map[string]interface{}{"continuationCommand": map[string]interface{}{"token": token}},
+	}}
+}
+
+// handleVideoplayback serves the content with Range support,
+// a missing signature or a throttled n-parameter are rejected
+func (s *Server) handleVideoplayback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	v, ok := s.video(query.Get("id"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if query.Get("n") != decodedN(v.ID) {
+		http.Error(w, "throttled", http.StatusForbidden)
+		return
+	}
+	if v.Ciphered && query.Get("sig") != signature(v.ID) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+
+	http.ServeContent(w, r, "videoplayback", time.Time{}, bytes.NewReader(v.Content))
+}
+
+// handleThumbnail serves hqdefault of every video, the other sizes are missing
+func (s *Server) handleThumbnail(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) != 4 || parts[3] != "hqdefault.jpg" {
+		http.NotFound(w, r)
+		return
+	}
+	if _, ok := s.video(parts[2]); !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Write(Thumbnail)
+}
+
+// Thumbnail is the image served as thumbnail of every video, the start of a JPEG
+var Thumbnail = []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00")
@@ -0,0 +1,55 @@
+package youtubetest
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	content := []byte(strings.Repeat("x", 100))
+	fake := NewServer(Video{ID: "BaW_jenozKc", Content: content})
+	dir := t.TempDir()
+
+	recorder, err := NewRecorder(dir, nil)
+	require.NoError(t, err)
+	recorder.MaxStreamBody = 10
+
+	get := func(transport http.RoundTripper, path string) (int, string) {
+		resp, err := (&http.Client{Transport: transport}).Get(fake.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	stream := "/videoplayback?id=BaW_jenozKc&itag=18&n=" + decodedN("BaW_jenozKc")
+	_, body := get(recorder, stream)
+	assert.Equal(t, string(content), body)
+	_, player := get(recorder, PlayerPath)
+	status, _ := get(recorder, "/vi/BaW_jenozKc/maxresdefault.jpg")
+	assert.Equal(t, http.StatusNotFound, status)
+	fake.Close()
+
+	replayer, err := NewReplayer(dir)
+	require.NoError(t, err)
+
+	// the query order doesn't matter
+	_, body = get(replayer, "/videoplayback?n="+decodedN("BaW_jenozKc")+"&itag=18&id=BaW_jenozKc")
+	assert.Equal(t, string(content[:10]), body)
+
+	for i := 0; i < 2; i++ {
+		_, body = get(replayer, PlayerPath)
+		assert.Equal(t, player, body)
+	}
+	status, _ = get(replayer, "/vi/BaW_jenozKc/maxresdefault.jpg")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	_, err = (&http.Client{Transport: replayer}).Get(fake.URL + "/embed/BaW_jenozKc")
+	assert.Error(t, err)
+}